@@ -1,37 +1,140 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/olekukonko/tablewriter"
 	"github.com/tinc-boot/tincd/network"
+	"golang.org/x/crypto/ssh/terminal"
 	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 	"tinc-web-boot/support/go/tincweb"
+	"tinc-web-boot/support/go/tincwebui"
 	"tinc-web-boot/web/shared"
 )
 
+const (
+	defaultURL = "http://127.0.0.1:8686/api"
+	socketURL  = "http://unix/rpc"
+)
+
 type baseParam struct {
-	URL       string   `name:"url" env:"URL" help:"API URL for tinc-web-boot" default:"http://127.0.0.1:8686/api"`
+	URL       string   `name:"url" env:"URL" help:"API URL for tinc-web-boot, default is http://127.0.0.1:8686/api. Token is passed in URL path for .../api URLs and in Authorization header for others (use .../rpc if daemon runs with --no-token-path)"`
 	Token     string   `name:"token" env:"TOKEN" help:"Access token for API" default:"local"`
 	TokenFile []string `short:"f" long:"token-file" env:"TOKEN_FILE" description:"Token file" default:".tinc-web-boot,/etc/tinc-web-boot/.tinc-web-boot"`
+	Socket    string   `name:"socket" env:"SOCKET" help:"Unix socket of tinc-web-boot, used instead of token if exists and URL is not set. Default URL with token is used if socket is not accessible (empty - disabled)" default:"/var/run/tinc-web-boot.sock"`
 }
 
 func (bp baseParam) Client() *tincweb.TincWebClient {
+	target, client := bp.endpoint()
+	return &tincweb.TincWebClient{BaseURL: target, HTTP: client}
+}
+
+func (bp baseParam) UIClient() *tincwebui.TincWebUIClient {
+	target, client := bp.endpoint()
+	return &tincwebui.TincWebUIClient{BaseURL: target, HTTP: client}
+}
+
+// API URL and HTTP client for it
+func (bp baseParam) endpoint() (string, *http.Client) {
+	target, transport := bp.tokenEndpoint()
+	if info, err := os.Stat(bp.Socket); bp.URL == "" && bp.Socket != "" && err == nil && info.Mode()&os.ModeSocket != 0 {
+		// daemon authorizes local process by its credentials, token is not needed
+		fallbackURL, err := url.Parse(target)
+		if err != nil {
+			return socketURL, &http.Client{Transport: socketTransport(bp.Socket)}
+		}
+		return socketURL, &http.Client{Transport: &fallbackTransport{
+			socket:   socketTransport(bp.Socket),
+			fallback: transport,
+			url:      fallbackURL,
+		}}
+	}
+	return target, &http.Client{Transport: transport}
+}
+
+// API URL with token (in path or in header by transport)
+func (bp baseParam) tokenEndpoint() (string, http.RoundTripper) {
+	if bp.URL == "" {
+		bp.URL = defaultURL
+	}
 	if len(bp.TokenFile) > 0 && (bp.Token == "" || bp.Token == "local") {
 		for _, file := range bp.TokenFile {
 			data, err := ioutil.ReadFile(file)
 			if err == nil {
-				bp.Token = string(data)
+				bp.Token = strings.TrimSpace(string(data))
 				break
 			}
 		}
 	}
-	return &tincweb.TincWebClient{BaseURL: bp.URL + "/" + bp.Token}
+	if strings.HasSuffix(bp.URL, "/api") {
+		return bp.URL + "/" + bp.Token, http.DefaultTransport
+	}
+	if bp.Token != "" && bp.Token != "local" {
+		return bp.URL, &bearerTransport{token: bp.Token, next: http.DefaultTransport}
+	}
+	return bp.URL, http.DefaultTransport
+}
+
+// transport with all requests sent to unix socket
+func socketTransport(socket string) http.RoundTripper {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+}
+
+// sends requests to socket and repeats them by URL with token if socket is not accessible for the user:
+// connection is refused or peer is not authorized
+type fallbackTransport struct {
+	socket   http.RoundTripper
+	fallback http.RoundTripper
+	url      *url.URL
+}
+
+func (ft *fallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := ft.socket.RoundTrip(req)
+	if err == nil && res.StatusCode != http.StatusUnauthorized && res.StatusCode != http.StatusForbidden {
+		return res, nil
+	}
+	if req.GetBody == nil {
+		// request could not be repeated
+		return res, err
+	}
+	if err == nil {
+		_ = res.Body.Close()
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	retry.URL = ft.url
+	retry.Host = ft.url.Host
+	return ft.fallback.RoundTrip(retry)
+}
+
+// adds Authorization header to requests
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (bt *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+bt.token)
+	return bt.next.RoundTrip(req)
 }
 
 type listNetworks struct {
@@ -44,10 +147,10 @@ func (m *listNetworks) Run(global *globalContext) error {
 		return err
 	}
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Network", "Running"})
+	table.SetHeader([]string{"Network", "Running", "Autostart"})
 	for _, net := range list {
 		table.Append([]string{
-			net.Name, fmt.Sprint(net.Running),
+			net.Name, fmt.Sprint(net.Running), fmt.Sprint(net.Autostart),
 		})
 	}
 	table.Render()
@@ -70,15 +173,37 @@ func (m *getNetwork) Run(global *globalContext) error {
 
 type shareNetwork struct {
 	baseParam
-	Output string `short:"o" name:"output" env:"OUTPUT" help:"Output file (empty or - for stdout)" default:"-"`
-	Name   string `arg:"name" required:"yes"`
+	Output     string `short:"o" name:"output" env:"OUTPUT" help:"Output file (empty or - for stdout)" default:"-"`
+	Encrypt    bool   `name:"encrypt" help:"Encrypt file by passphrase"`
+	Passphrase string `name:"passphrase" env:"PASSPHRASE" help:"Passphrase for encryption (asked if not defined)"`
+	Name       string `arg:"name" required:"yes"`
 }
 
 func (m *shareNetwork) Run(global *globalContext) error {
-	share, err := m.Client().Share(global.ctx, m.Name)
+	var (
+		share *shared.Sharing
+		err   error
+	)
+	if m.Encrypt {
+		var passphrase string
+		passphrase, err = readPassphrase(m.Passphrase, true)
+		if err != nil {
+			return err
+		}
+		share, err = m.Client().ShareEncrypted(global.ctx, m.Name, shared.ShareOptions{Passphrase: passphrase})
+	} else {
+		share, err = m.Client().Share(global.ctx, m.Name)
+	}
 	if err != nil {
 		return err
 	}
+	if share.Encrypted != nil {
+		fmt.Fprintln(os.Stderr, "Encrypted by passphrase")
+	}
+	if share.Signature != nil {
+		// fingerprint should be passed to receiver by trusted channel
+		fmt.Fprintln(os.Stderr, "Signed by", share.Signature.Signer)
+	}
 	var f = os.Stdout
 	if m.Output != "" && m.Output != "-" {
 		fs, err := os.Create(m.Output)
@@ -95,8 +220,11 @@ func (m *shareNetwork) Run(global *globalContext) error {
 
 type importNetwork struct {
 	baseParam
-	Input string `short:"i" name:"input" env:"INPUT" help:"Input file (empty or - for stdin)" default:"-"`
-	Name  string `arg:"name" help:"optional name for network" optional:"yes"`
+	Input      string `short:"i" name:"input" env:"INPUT" help:"Input file (empty or - for stdin)" default:"-"`
+	Signer     string `name:"signer" env:"SIGNER" help:"Expected fingerprint of signer key (SHA256:...)"`
+	Force      bool   `name:"force" help:"Import unsigned file or file signed by untrusted key"`
+	Passphrase string `name:"passphrase" env:"PASSPHRASE" help:"Passphrase for encrypted file (asked if not defined)"`
+	Name       string `arg:"name" help:"optional name for network" optional:"yes"`
 }
 
 func (m *importNetwork) Run(global *globalContext) error {
@@ -115,11 +243,103 @@ func (m *importNetwork) Run(global *globalContext) error {
 	if err != nil {
 		return err
 	}
-	if m.Name != "" {
-		cfg.Name = m.Name
+	var passphrase string
+	if cfg.Encrypted != nil {
+		passphrase, err = readPassphrase(m.Passphrase, false)
+		if err != nil {
+			return err
+		}
+	}
+	res, err := m.Client().ImportSigned(global.ctx, cfg, shared.ImportOptions{
+		Name:       m.Name,
+		Signer:     m.Signer,
+		Force:      m.Force,
+		Passphrase: passphrase,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Network:", res.Network.Name)
+	switch {
+	case res.Signer == "":
+		fmt.Println("Signer: none (not signed)")
+	case res.Trusted:
+		fmt.Println("Signer:", res.Signer)
+	default:
+		fmt.Println("Signer:", res.Signer, "(not trusted before, now trusted for the network)")
 	}
-	_, err = m.Client().Import(global.ctx, cfg)
-	return err
+	return nil
+}
+
+type backup struct {
+	Create  createBackup  `cmd:"create" help:"Backup all networks to archive"`
+	Restore restoreBackup `cmd:"restore" help:"Restore networks from backup archive"`
+}
+
+type createBackup struct {
+	baseParam
+	Output string `short:"o" name:"output" env:"OUTPUT" help:"Output file (empty or - for stdout)" default:"-"`
+}
+
+func (m *createBackup) Run(global *globalContext) error {
+	res, err := m.Client().Backup(global.ctx)
+	if err != nil {
+		return err
+	}
+	var f = os.Stdout
+	if m.Output != "" && m.Output != "-" {
+		fs, err := os.OpenFile(m.Output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer fs.Close()
+		f = fs
+	}
+	_, err = f.Write(res.Archive)
+	if err != nil {
+		return err
+	}
+	for _, item := range res.Manifest.Networks {
+		log.Println("saved", item.Name, "with", len(item.Files), "files")
+	}
+	return nil
+}
+
+type restoreBackup struct {
+	baseParam
+	Input        string   `short:"i" name:"input" env:"INPUT" help:"Input file (empty or - for stdin)" default:"-"`
+	SkipExisting bool     `name:"skip-existing" env:"SKIP_EXISTING" help:"Do not replace existent networks"`
+	Network      []string `arg:"network" help:"Networks to restore (default - all)" optional:"yes"`
+}
+
+func (m *restoreBackup) Run(global *globalContext) error {
+	var f = os.Stdin
+	if m.Input != "" && m.Input != "-" {
+		fs, err := os.Open(m.Input)
+		if err != nil {
+			return err
+		}
+		defer fs.Close()
+		f = fs
+	}
+	archive, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	res, err := m.Client().RestoreBackup(global.ctx, archive, shared.RestoreOptions{
+		Networks:     m.Network,
+		SkipExisting: m.SkipExisting,
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range res.Restored {
+		fmt.Println("restored:", name)
+	}
+	for _, name := range res.Skipped {
+		fmt.Println("skipped:", name)
+	}
+	return nil
 }
 
 type peers struct {
@@ -145,12 +365,44 @@ func (m *peers) Run(global *globalContext) error {
 
 type create struct {
 	baseParam
-	Network string `arg:"network" required:"yes"`
-	Subnet  string `arg:"subnet" required:"yes"`
+	Template string `short:"t" name:"template" env:"TEMPLATE" help:"Template for network settings"`
+	Network  string `arg:"network" required:"yes"`
+	Subnet   string `arg:"subnet" optional:"yes" help:"Network subnet (default - from template or automatically allocated)"`
 }
 
 func (m *create) Run(global *globalContext) error {
-	info, err := m.Client().Create(global.ctx, m.Network, m.Subnet)
+	info, err := m.Client().CreateFromTemplate(global.ctx, m.Network, m.Subnet, m.Template)
+	if err != nil {
+		return err
+	}
+	printNetwork(info)
+	return nil
+}
+
+type rename struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	NewName string `arg:"new-name" required:"yes"`
+}
+
+func (m *rename) Run(global *globalContext) error {
+	info, err := m.Client().Rename(global.ctx, m.Network, m.NewName)
+	if err != nil {
+		return err
+	}
+	printNetwork(info)
+	return nil
+}
+
+type clone struct {
+	baseParam
+	Source  string `arg:"source" required:"yes"`
+	Network string `arg:"network" required:"yes"`
+	Subnet  string `arg:"subnet" optional:"yes" help:"Subnet of new network (default - automatically allocated)"`
+}
+
+func (m *clone) Run(global *globalContext) error {
+	info, err := m.Client().Clone(global.ctx, m.Source, m.Network, m.Subnet)
 	if err != nil {
 		return err
 	}
@@ -174,6 +426,45 @@ func (m *remove) Run(global *globalContext) error {
 	return nil
 }
 
+type trash struct {
+	List    listTrash    `cmd:"list" help:"List removed networks"`
+	Restore restoreTrash `cmd:"restore" help:"Restore removed network"`
+}
+
+type listTrash struct {
+	baseParam
+}
+
+func (m *listTrash) Run(global *globalContext) error {
+	list, err := m.Client().Trash(global.ctx)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Network", "Removed", "Expires"})
+	for _, item := range list {
+		table.Append([]string{
+			item.ID, item.Network, item.Removed.Format(time.RFC3339), item.Expires.Format(time.RFC3339),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type restoreTrash struct {
+	baseParam
+	ID string `arg:"id" required:"yes"`
+}
+
+func (m *restoreTrash) Run(global *globalContext) error {
+	info, err := m.Client().Restore(global.ctx, m.ID)
+	if err != nil {
+		return err
+	}
+	printNetwork(info)
+	return nil
+}
+
 type upgrade struct {
 	baseParam
 	PublicAddress []string `short:"A" name:"public-address" env:"PUBLIC_ADDRESS" help:"Public node address"`
@@ -199,10 +490,16 @@ func (m *upgrade) Run(global *globalContext) error {
 		})
 	}
 
-	_, err := m.Client().Upgrade(global.ctx, m.Network, params)
+	result, err := m.Client().Upgrade(global.ctx, m.Network, params)
 	if err != nil {
 		return err
 	}
+	switch {
+	case result.Reloaded:
+		fmt.Println("configuration reloaded")
+	case result.RestartRequired:
+		fmt.Println("restart required (running network restarted)")
+	}
 	return nil
 }
 
@@ -234,21 +531,197 @@ func (m *stop) Run(global *globalContext) error {
 	return nil
 }
 
+type status struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *status) Run(global *globalContext) error {
+	info, err := m.Client().Status(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Name:", info.Network)
+	fmt.Println("Running:", info.Running)
+	if info.PID != 0 {
+		fmt.Println("PID:", info.PID)
+	}
+	if info.Started != nil {
+		fmt.Println("Started:", info.Started.Format(time.RFC3339))
+		fmt.Println("Uptime:", info.Uptime)
+	}
+	fmt.Println("Restarts:", info.Restarts)
+	if info.LastError != "" {
+		fmt.Println("Last error:", info.LastError)
+	}
+	fmt.Println("Interface:", info.Interface)
+	for _, addr := range info.Addresses {
+		fmt.Println("Address:", addr)
+	}
+	fmt.Println("Port:", info.Port)
+	fmt.Println("Peers:", info.OnlinePeers, "online of", info.KnownPeers, "known")
+	return nil
+}
+
 type invite struct {
 	baseParam
 	Lifetime time.Duration `name:"lifetime" env:"LIFETIME" help:"How long invitation will work" default:"1h"`
+	MaxUses  int           `name:"max-uses" help:"Maximum number of joins by invitation (0 - unlimited)" default:"0"`
+	NodeName string        `name:"node-name" help:"Only node with this name could join"`
+	Approval bool          `name:"approval" help:"Joins should be approved by admin (see requests command)"`
 	Network  string        `arg:"network" required:"yes"`
 }
 
 func (m *invite) Run(global *globalContext) error {
-	link, err := m.Client().Majordomo(global.ctx, m.Network, m.Lifetime)
+	info, err := m.Client().Invite(global.ctx, m.Network, shared.InviteOptions{
+		Lifetime: m.Lifetime,
+		MaxUses:  m.MaxUses,
+		NodeName: m.NodeName,
+		Approval: m.Approval,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(info.Link)
+	return nil
+}
+
+type invitations struct {
+	List   listInvitations  `cmd:"list" help:"List invitations to network"`
+	Show   showInvitation   `cmd:"show" help:"Show invitation and its redemptions"`
+	Revoke revokeInvitation `cmd:"revoke" help:"Revoke invitation"`
+}
+
+type listInvitations struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *listInvitations) Run(global *globalContext) error {
+	list, err := m.Client().Invitations(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Node", "Uses", "Expires", "Active"})
+	for _, item := range list {
+		table.Append([]string{
+			item.ID, item.NodeName, invitationUses(item), item.Expires.Format(time.RFC3339), strconv.FormatBool(item.IsActive()),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type showInvitation struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	ID      string `arg:"id" required:"yes" help:"Invitation ID"`
+}
+
+func (m *showInvitation) Run(global *globalContext) error {
+	info, err := m.Client().Invitation(global.ctx, m.Network, m.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Println("ID:", info.ID)
+	if info.NodeName != "" {
+		fmt.Println("Node:", info.NodeName)
+	}
+	fmt.Println("Uses:", invitationUses(info))
+	fmt.Println("Created:", info.Created.Format(time.RFC3339))
+	fmt.Println("Expires:", info.Expires.Format(time.RFC3339))
+	fmt.Println("Approval:", info.Approval)
+	fmt.Println("Revoked:", info.Revoked)
+	fmt.Println("Active:", info.IsActive())
+	for _, item := range info.Redemptions {
+		fmt.Println("Joined:", item.Node, item.Address, item.Time.Format(time.RFC3339))
+	}
+	return nil
+}
+
+type revokeInvitation struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	ID      string `arg:"id" required:"yes" help:"Invitation ID"`
+}
+
+func (m *revokeInvitation) Run(global *globalContext) error {
+	ok, err := m.Client().RevokeInvitation(global.ctx, m.Network, m.ID)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Println("revoked")
+	}
+	return nil
+}
+
+type joinRequests struct {
+	List    listJoinRequests `cmd:"list" help:"List requests to join network"`
+	Approve approveJoin      `cmd:"approve" help:"Approve join request"`
+	Reject  rejectJoin       `cmd:"reject" help:"Reject join request"`
+}
+
+type listJoinRequests struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *listJoinRequests) Run(global *globalContext) error {
+	list, err := m.Client().JoinRequests(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Node", "Address", "Status", "Created"})
+	for _, item := range list {
+		table.Append([]string{
+			item.ID, item.Node.Name, item.Address, string(item.Status), item.Created.Format(time.RFC3339),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type approveJoin struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	ID      string `arg:"id" required:"yes" help:"Request ID"`
+}
+
+func (m *approveJoin) Run(global *globalContext) error {
+	info, err := m.Client().ApproveJoin(global.ctx, m.Network, m.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(info.Node.Name, info.Status)
+	return nil
+}
+
+type rejectJoin struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	ID      string `arg:"id" required:"yes" help:"Request ID"`
+}
+
+func (m *rejectJoin) Run(global *globalContext) error {
+	info, err := m.Client().RejectJoin(global.ctx, m.Network, m.ID)
 	if err != nil {
 		return err
 	}
-	fmt.Println(link)
+	fmt.Println(info.Node.Name, info.Status)
 	return nil
 }
 
+// used/maximum joins of invitation
+func invitationUses(inv *shared.Invitation) string {
+	if inv.MaxUses <= 0 {
+		return strconv.Itoa(len(inv.Redemptions)) + "/unlimited"
+	}
+	return strconv.Itoa(len(inv.Redemptions)) + "/" + strconv.Itoa(inv.MaxUses)
+}
+
 type join struct {
 	baseParam
 	NoStart bool   `name:"no-start" env:"NO_START" help:"Do not start network automatically"`
@@ -265,10 +738,60 @@ func (m *join) Run(global *globalContext) error {
 	return nil
 }
 
+type autostart struct {
+	Enable  enableAutostart  `cmd:"enable" help:"Start network automatically"`
+	Disable disableAutostart `cmd:"disable" help:"Do not start network automatically"`
+	List    listAutostart    `cmd:"list" help:"List networks with autostart"`
+}
+
+type enableAutostart struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *enableAutostart) Run(global *globalContext) error {
+	ntw, err := m.Client().EnableAutostart(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	fmt.Println("name:", ntw.Name, "autostart:", ntw.Autostart)
+	return nil
+}
+
+type disableAutostart struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *disableAutostart) Run(global *globalContext) error {
+	ntw, err := m.Client().DisableAutostart(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	fmt.Println("name:", ntw.Name, "autostart:", ntw.Autostart)
+	return nil
+}
+
+type listAutostart struct {
+	baseParam
+}
+
+func (m *listAutostart) Run(global *globalContext) error {
+	list, err := m.Client().AutostartNetworks(global.ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range list {
+		fmt.Println(name)
+	}
+	return nil
+}
+
 func printNetwork(info *shared.Network) {
 
 	fmt.Println("Name:", info.Name)
 	fmt.Println("Running:", info.Running)
+	fmt.Println("Autostart:", info.Autostart)
 	if info.Config == nil {
 		return
 	}
@@ -283,3 +806,321 @@ func printNetwork(info *shared.Network) {
 		fmt.Println("Connect to:", c)
 	}
 }
+
+type template struct {
+	List   listTemplates  `cmd:"list" help:"List templates"`
+	Get    getTemplate    `cmd:"get" help:"Show template"`
+	Save   saveTemplate   `cmd:"save" help:"Create or replace template"`
+	Remove removeTemplate `cmd:"remove" help:"Remove template"`
+}
+
+type listTemplates struct {
+	baseParam
+}
+
+func (m *listTemplates) Run(global *globalContext) error {
+	list, err := m.Client().Templates(global.ctx)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Template", "Subnet", "Mode", "Device type", "Ports"})
+	for _, tpl := range list {
+		var ports string
+		if tpl.MinPort != 0 {
+			ports = fmt.Sprint(tpl.MinPort, "-", tpl.MaxPort)
+		}
+		table.Append([]string{
+			tpl.Name, tpl.Subnet, tpl.Mode, tpl.DeviceType, ports,
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type getTemplate struct {
+	baseParam
+	Name string `arg:"name" required:"yes"`
+}
+
+func (m *getTemplate) Run(global *globalContext) error {
+	tpl, err := m.Client().Template(global.ctx, m.Name)
+	if err != nil {
+		return err
+	}
+	printTemplate(tpl)
+	return nil
+}
+
+type saveTemplate struct {
+	baseParam
+	Subnet        string   `name:"subnet" env:"SUBNET" help:"Default subnet"`
+	DeviceType    string   `name:"device-type" env:"DEVICE_TYPE" help:"Device type (tap, tun, ...)"`
+	Mode          string   `name:"mode" env:"MODE" help:"Mode (router, switch or hub)"`
+	MinPort       uint16   `name:"min-port" env:"MIN_PORT" help:"Lowest listening port"`
+	MaxPort       uint16   `name:"max-port" env:"MAX_PORT" help:"Highest listening port"`
+	PublicAddress []string `short:"A" name:"public-address" env:"PUBLIC_ADDRESS" help:"Public node address (host or host:port)"`
+	Compression   int      `name:"compression" env:"COMPRESSION" help:"Compression level (0-11)"`
+	Script        []string `name:"script" help:"User script as name=file (host-up, subnet-up, ...)"`
+	Name          string   `arg:"name" required:"yes"`
+}
+
+func (m *saveTemplate) Run(global *globalContext) error {
+	tpl := shared.Template{
+		Name:        m.Name,
+		Subnet:      m.Subnet,
+		DeviceType:  m.DeviceType,
+		Mode:        m.Mode,
+		MinPort:     m.MinPort,
+		MaxPort:     m.MaxPort,
+		Compression: m.Compression,
+	}
+	for _, addr := range m.PublicAddress {
+		address, err := parseAddress(addr)
+		if err != nil {
+			return err
+		}
+		tpl.Address = append(tpl.Address, address)
+	}
+	for _, script := range m.Script {
+		kv := strings.SplitN(script, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("script should be defined as name=file")
+		}
+		data, err := ioutil.ReadFile(kv[1])
+		if err != nil {
+			return err
+		}
+		if tpl.Scripts == nil {
+			tpl.Scripts = make(map[string]string)
+		}
+		tpl.Scripts[kv[0]] = string(data)
+	}
+	saved, err := m.Client().SaveTemplate(global.ctx, tpl)
+	if err != nil {
+		return err
+	}
+	printTemplate(saved)
+	return nil
+}
+
+type removeTemplate struct {
+	baseParam
+	Name string `arg:"name" required:"yes"`
+}
+
+func (m *removeTemplate) Run(global *globalContext) error {
+	ok, err := m.Client().RemoveTemplate(global.ctx, m.Name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Println("removed")
+	}
+	return nil
+}
+
+type lease struct {
+	List   listLeases  `cmd:"list" help:"List leased addresses"`
+	Set    setLease    `cmd:"set" help:"Reserve address for node"`
+	Remove removeLease `cmd:"remove" help:"Remove lease of node"`
+}
+
+type listLeases struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+}
+
+func (m *listLeases) Run(global *globalContext) error {
+	list, err := m.Client().Leases(global.ctx, m.Network)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Node", "IP", "Updated"})
+	for _, item := range list {
+		table.Append([]string{
+			item.Node, item.IP, item.Updated.Format(time.RFC3339),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type setLease struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	Node    string `arg:"node" required:"yes"`
+	IP      string `arg:"ip" required:"yes"`
+}
+
+func (m *setLease) Run(global *globalContext) error {
+	item, err := m.Client().SetLease(global.ctx, m.Network, m.Node, m.IP)
+	if err != nil {
+		return err
+	}
+	fmt.Println(item.Node, item.IP)
+	return nil
+}
+
+type removeLease struct {
+	baseParam
+	Network string `arg:"network" required:"yes"`
+	Node    string `arg:"node" required:"yes"`
+}
+
+func (m *removeLease) Run(global *globalContext) error {
+	ok, err := m.Client().RemoveLease(global.ctx, m.Network, m.Node)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Println("removed")
+	}
+	return nil
+}
+
+type token struct {
+	Issue  issueToken  `cmd:"issue" help:"Issue access token for role"`
+	List   listTokens  `cmd:"list" help:"List issued tokens and invitation links"`
+	Revoke revokeToken `cmd:"revoke" help:"Revoke token by ID"`
+}
+
+type issueToken struct {
+	baseParam
+	Role  string `name:"role" short:"r" enum:"admin,operator,viewer" default:"viewer" help:"Role of token holder: admin, operator (manage networks) or viewer (read-only)"`
+	Label string `name:"label" short:"l" help:"Label of token to find it later"`
+	Days  uint   `name:"days" short:"d" default:"30" help:"Validity of token in days"`
+}
+
+func (m *issueToken) Run(global *globalContext) error {
+	tok, err := m.UIClient().IssueToken(global.ctx, shared.Role(m.Role), m.Label, m.Days)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tok)
+	return nil
+}
+
+type listTokens struct {
+	baseParam
+}
+
+func (m *listTokens) Run(global *globalContext) error {
+	list, err := m.UIClient().ListTokens(global.ctx)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Role", "Label", "Issued", "Expires"})
+	for _, item := range list {
+		table.Append([]string{
+			item.ID, string(item.Role), item.Label, item.Issued.Format(time.RFC3339), item.Expires.Format(time.RFC3339),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+type revokeToken struct {
+	baseParam
+	ID string `arg:"id" required:"yes" help:"Token ID"`
+}
+
+func (m *revokeToken) Run(global *globalContext) error {
+	ok, err := m.UIClient().RevokeToken(global.ctx, m.ID)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Println("revoked")
+	}
+	return nil
+}
+
+type rotateKey struct {
+	baseParam
+	Grace time.Duration `name:"grace" default:"24h" help:"Time while tokens signed by previous keys are still accepted"`
+}
+
+func (m *rotateKey) Run(global *globalContext) error {
+	list, err := m.UIClient().RotateKey(global.ctx, m.Grace)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Key ID", "Created", "Expires"})
+	for _, item := range list {
+		var expires = "current"
+		if !item.Expires.IsZero() {
+			expires = item.Expires.Format(time.RFC3339)
+		}
+		table.Append([]string{
+			item.ID, item.Created.Format(time.RFC3339), expires,
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func printTemplate(tpl *shared.Template) {
+	fmt.Println("Name:", tpl.Name)
+	fmt.Println("Subnet:", tpl.Subnet)
+	fmt.Println("Mode:", tpl.Mode)
+	fmt.Println("Device type:", tpl.DeviceType)
+	if tpl.MinPort != 0 {
+		fmt.Println("Ports:", tpl.MinPort, "-", tpl.MaxPort)
+	}
+	for _, addr := range tpl.Address {
+		fmt.Println("Public address:", addr.String())
+	}
+	fmt.Println("Compression:", tpl.Compression)
+	for name := range tpl.Scripts {
+		fmt.Println("Script:", name)
+	}
+}
+
+// passphrase from option or terminal. New passphrase is asked twice
+func readPassphrase(defined string, confirm bool) (string, error) {
+	if defined != "" {
+		return defined, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("passphrase is required (--passphrase or PASSPHRASE)")
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		repeated, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(repeated) != string(passphrase) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+// parse host or host:port
+func parseAddress(addr string) (network.Address, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return network.Address{Host: addr}, nil
+	}
+	portV, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return network.Address{}, err
+	}
+	return network.Address{Host: host, Port: uint16(portV)}, nil
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/alecthomas/kong"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"tinc-web-boot/pool"
+	"tinc-web-boot/pool/simulator"
+	"tinc-web-boot/support/go/tincweb"
+	"tinc-web-boot/web"
+)
+
+type testDaemon struct {
+	pool   *pool.Pool
+	runner *simulator.Runner
+	server *httptest.Server
+	socket string
+	dir    string
+	cancel func()
+}
+
+func newTestDaemon(t *testing.T) *testDaemon {
+	dir, err := ioutil.TempDir("", "cli-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "networks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	td := &testDaemon{
+		runner: &simulator.Runner{},
+		dir:    dir,
+		cancel: cancel,
+	}
+	td.pool, err = pool.NewWithRunner(ctx, filepath.Join(dir, "tinc-web.json"), filepath.Join(dir, "networks"), "tincd", td.runner)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	router, _ := web.Config{}.New(td.pool)
+	td.server = httptest.NewServer(router)
+	return td
+}
+
+func (td *testDaemon) Close() {
+	td.server.Close()
+	td.pool.Stop()
+	td.cancel()
+	_ = os.RemoveAll(td.dir)
+}
+
+// run CLI command against test daemon and return output
+func (td *testDaemon) run(t *testing.T, args ...string) string {
+	var cli Main
+	parser, err := kong.New(&cli, kong.Vars{"version": version}, kong.Resolvers(&optionsResolver{}), kong.Exit(func(int) {
+		t.Fatal("unexpected exit")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if td.socket == "" {
+		args = append(args, "--url", td.server.URL+"/rpc")
+	}
+	args = append(args, "--token-file", filepath.Join(td.dir, "missing-token"), "--socket="+td.socket)
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		_, _ = io.Copy(&output, reader)
+	}()
+	stdout := os.Stdout
+	os.Stdout = writer
+	err = ctx.Run(&globalContext{ctx: context.Background()})
+	os.Stdout = stdout
+	_ = writer.Close()
+	<-copied
+	if err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return output.String()
+}
+
+func TestCLI_Networks(t *testing.T) {
+	td := newTestDaemon(t)
+	defer td.Close()
+
+	out := td.run(t, "new", "alpha", "10.155.0.0/16")
+	if !strings.Contains(out, "Name: alpha") {
+		t.Errorf("unexpected output of new: %s", out)
+	}
+
+	out = td.run(t, "start", "alpha")
+	if !strings.Contains(out, "running: true") {
+		t.Errorf("unexpected output of start: %s", out)
+	}
+	td.runner.Process("alpha").Join("beta")
+
+	out = td.run(t, "status", "alpha")
+	if !strings.Contains(out, "Running: true") || !strings.Contains(out, "Peers: 1 online of 0 known") {
+		t.Errorf("unexpected output of status: %s", out)
+	}
+
+	td.run(t, "autostart", "enable", "alpha")
+	out = td.run(t, "list")
+	if !strings.Contains(out, "alpha") || strings.Count(out, "true") != 2 {
+		t.Errorf("unexpected output of list: %s", out)
+	}
+
+	out = td.run(t, "stop", "alpha")
+	if !strings.Contains(out, "running: false") {
+		t.Errorf("unexpected output of stop: %s", out)
+	}
+
+	out = td.run(t, "delete", "alpha")
+	if !strings.Contains(out, "removed") {
+		t.Errorf("unexpected output of delete: %s", out)
+	}
+	out = td.run(t, "trash", "list")
+	if !strings.Contains(out, "alpha") {
+		t.Errorf("unexpected output of trash list: %s", out)
+	}
+}
+
+func TestCLI_Tokens(t *testing.T) {
+	td := newTestDaemon(t)
+	defer td.Close()
+
+	out := td.run(t, "token", "issue", "--role", "operator", "--label", "ci", "--days", "7")
+	if strings.Count(strings.TrimSpace(out), ".") != 2 {
+		t.Errorf("unexpected output of token issue: %s", out)
+	}
+	out = td.run(t, "token", "list")
+	id := regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f-]{27}`).FindString(out)
+	if !strings.Contains(out, "operator") || !strings.Contains(out, "ci") || id == "" {
+		t.Fatalf("unexpected output of token list: %s", out)
+	}
+	out = td.run(t, "token", "revoke", id)
+	if !strings.Contains(out, "revoked") {
+		t.Errorf("unexpected output of token revoke: %s", out)
+	}
+	out = td.run(t, "token", "list")
+	if strings.Contains(out, id) {
+		t.Errorf("revoked token should not be listed: %s", out)
+	}
+}
+
+func TestCLI_Sharing(t *testing.T) {
+	td := newTestDaemon(t)
+	defer td.Close()
+	file := filepath.Join(td.dir, "alpha.json")
+
+	td.run(t, "new", "alpha", "10.155.0.0/16")
+	td.run(t, "share", "--encrypt", "--passphrase", "secret", "-o", file, "alpha")
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "scrypt") || strings.Contains(string(data), "10.155.0.0/16") {
+		t.Errorf("shared file should be encrypted: %s", data)
+	}
+	// own document is trusted
+	out := td.run(t, "import", "--passphrase", "secret", "-i", file)
+	if !strings.Contains(out, "Network: alpha") || !strings.Contains(out, "Signer: SHA256:") || strings.Contains(out, "not trusted") {
+		t.Errorf("unexpected output of import: %s", out)
+	}
+}
+
+func TestCLI_Backup(t *testing.T) {
+	td := newTestDaemon(t)
+	defer td.Close()
+	file := filepath.Join(td.dir, "backup.tar.gz")
+
+	td.run(t, "new", "alpha", "10.155.0.0/16")
+	td.run(t, "backup", "create", "-o", file)
+	td.run(t, "delete", "alpha")
+	out := td.run(t, "backup", "restore", "-i", file, "alpha")
+	if !strings.Contains(out, "restored: alpha") {
+		t.Errorf("unexpected output of restore: %s", out)
+	}
+	// removed copy is kept in trash
+	out = td.run(t, "trash", "list")
+	if !strings.Contains(out, "alpha") {
+		t.Errorf("removed network should be kept in trash: %s", out)
+	}
+}
+
+func TestCLI_Socket(t *testing.T) {
+	if !web.SocketSupported {
+		t.Skip("unix socket API is not supported")
+	}
+	td := newTestDaemon(t)
+	defer td.Close()
+	local := newTestDaemon(t)
+	defer local.Close()
+	td.socket = filepath.Join(td.dir, "api.sock")
+	listener, err := web.ListenSocket(td.socket, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: local.server.Config.Handler, ConnContext: web.ConnContext}
+	go server.Serve(listener)
+	defer server.Close()
+
+	// URL is not set, so socket should be used
+	td.run(t, "new", "alpha", "10.155.0.0/16")
+	out := td.run(t, "list")
+	if !strings.Contains(out, "alpha") {
+		t.Errorf("unexpected output of list: %s", out)
+	}
+	if ntw, _ := local.pool.Network("alpha"); !ntw.IsDefined() {
+		t.Error("network should be created through socket")
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Error("default HTTP client should not be changed")
+	}
+	// explicit URL has priority over socket
+	out = td.run(t, "list", "--url", td.server.URL+"/rpc")
+	if strings.Contains(out, "alpha") {
+		t.Errorf("explicit URL should be used instead of socket: %s", out)
+	}
+}
+
+func TestCLI_SocketFallback(t *testing.T) {
+	if !web.SocketSupported {
+		t.Skip("unix socket API is not supported")
+	}
+	td := newTestDaemon(t)
+	defer td.Close()
+	td.run(t, "new", "alpha", "10.155.0.0/16")
+	target, err := url.Parse(td.server.URL + "/rpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(td.dir, "api.sock")
+	listener, err := web.ListenSocket(socket, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "peer is not authorized", http.StatusForbidden)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	// peer is not authorized by socket and socket could not be dialed
+	for _, file := range []string{socket, filepath.Join(td.dir, "missing.sock")} {
+		client := &tincweb.TincWebClient{BaseURL: socketURL, HTTP: &http.Client{Transport: &fallbackTransport{
+			socket:   socketTransport(file),
+			fallback: http.DefaultTransport,
+			url:      target,
+		}}}
+		list, err := client.Networks(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(list) != 1 || list[0].Name != "alpha" {
+			t.Errorf("%s: unexpected networks: %+v", file, list)
+		}
+	}
+}
+
+func TestRoot_DaemonConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if err := ioutil.WriteFile(configFile, []byte(`{"auto_start":["alpha"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("networks", 0755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join("networks", configFile)
+	if err := moveLegacyConfig(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Error("legacy config should be removed")
+	}
+	if info, err := os.Stat(target); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("moved config should be private: %v", info.Mode())
+	}
+	var cfg pool.Config
+	if err := cfg.LoadFrom(target); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.AutoStart.Has("alpha") || cfg.Version != pool.ConfigVersion {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if err := ioutil.WriteFile("auth.key", []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	daemon := pool.DaemonConfig{
+		UIPublicAddress: []string{"example.com:8686"},
+		AuthKeyFile:     "auth.key",
+		Bind:            "0.0.0.0:9999",
+		TLS:             true,
+	}
+	root, options := parseRoot(t, "run", "--bind", "127.0.0.1:9000", "--tls=false")
+	if err := root.applyDaemonConfig(daemon, options.IsSet); err != nil {
+		t.Fatal(err)
+	}
+	if root.Bind != "127.0.0.1:9000" || root.TLS {
+		t.Errorf("flags should take priority over config: %+v", root.HttpServer)
+	}
+	if root.AuthKey != "secret" || root.CertFile != "server.crt" || len(root.UIPublicAddress) != 1 {
+		t.Errorf("settings should be taken from config and defaults: %+v", root)
+	}
+
+	root, options = parseRoot(t, "run")
+	if err := root.applyDaemonConfig(daemon, options.IsSet); err != nil {
+		t.Fatal(err)
+	}
+	if root.Bind != "0.0.0.0:9999" || !root.TLS {
+		t.Errorf("settings should be taken from config: %+v", root.HttpServer)
+	}
+}
+
+func parseRoot(t *testing.T, args ...string) (*Root, *optionsResolver) {
+	var cli Main
+	options := &optionsResolver{}
+	parser, err := kong.New(&cli, kong.Vars{"version": version}, kong.Resolvers(options), kong.Exit(func(int) {
+		t.Fatal("unexpected exit")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return &cli.Run, options
+}
+
+func parseArgs(t *testing.T, args ...string) (*Main, *kong.Context) {
+	var cli Main
+	parser, err := kong.New(&cli, kong.Vars{"version": version}, kong.Resolvers(&optionsResolver{}), kong.Exit(func(int) {
+		t.Fatal("unexpected exit")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cli, ctx
+}
+
+func TestConfig_DumpAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "options.json")
+
+	_, ctx := parseArgs(t, "config", "dump", "--bind", "127.0.0.1:9000", "-A", "example.com:9000", "--tls",
+		"--graceful-shutdown", "3s", "--dir", "/var/lib/tinc-web-boot", file)
+	if err := ctx.Run(&globalContext{ctx: context.Background()}); err != nil {
+		t.Fatal(err)
+	}
+
+	cli, _ := parseArgs(t, "run", "--config", file, "--dir", "/tmp/networks")
+	if cli.Run.Bind != "127.0.0.1:9000" || !cli.Run.TLS || cli.Run.GracefulShutdown != 3*time.Second {
+		t.Errorf("options should be loaded from config: %+v", cli.Run)
+	}
+	if len(cli.Run.UIPublicAddress) != 1 || cli.Run.UIPublicAddress[0] != "example.com:9000" {
+		t.Errorf("unexpected public addresses: %v", cli.Run.UIPublicAddress)
+	}
+	if cli.Run.Dir != "/tmp/networks" {
+		t.Error("flag should take priority over config:", cli.Run.Dir)
+	}
+
+	if err := os.Setenv("BIND", "127.0.0.1:9999"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("BIND")
+	cli, _ = parseArgs(t, "run", "--config", file)
+	if cli.Run.Bind != "127.0.0.1:9999" {
+		t.Error("environment variable should take priority over config:", cli.Run.Bind)
+	}
+	if cli.Run.Dir != "/var/lib/tinc-web-boot" {
+		t.Error("unexpected dir:", cli.Run.Dir)
+	}
+
+	// daemon keeps options when saves own settings to same file
+	var cfg pool.Config
+	if err := cfg.LoadFrom(file); err != nil {
+		t.Fatal(err)
+	}
+	cfg.AutoStart.Set("alpha")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	cli, _ = parseArgs(t, "run", "--config", file)
+	if cli.Run.Dir != "/var/lib/tinc-web-boot" || cli.Run.GracefulShutdown != 3*time.Second {
+		t.Errorf("options should be kept after daemon config save: %+v", cli.Run)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alecthomas/kong"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const configFlag = "config"
+
+type config struct {
+	Dump dumpConfig `cmd:"dump" help:"Write effective configuration of run command to file (could be used by --config)"`
+}
+
+type dumpConfig struct {
+	Root
+	Output string `arg:"output" required:"yes" help:"Output file"`
+}
+
+func (m *dumpConfig) Run(global *globalContext) error {
+	return Main{Run: m.Root}.dumpConfig(m.Output)
+}
+
+// Resolves options of run command from "run" section of file defined by --config flag.
+// Options are matched by json tags, so file produced by dumpConfig could be used as is.
+// Flags and environment variables take priority.
+// Resolver also remembers flags which are not set explicitly (by command line, environment or file).
+type optionsResolver struct {
+	loaded  bool
+	options map[string]json.RawMessage
+	unset   map[string]bool
+}
+
+func (r *optionsResolver) Validate(app *kong.Application) error { return nil }
+
+// Flag (by name) is set by command line, environment variable or options file
+func (r *optionsResolver) IsSet(flag string) bool {
+	return !r.unset[flag]
+}
+
+func (r *optionsResolver) Resolve(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+	if flag.Env != "" && os.Getenv(flag.Env) != "" {
+		return nil, nil
+	}
+	value, err := r.resolve(ctx, flag)
+	if err == nil && value == nil {
+		if r.unset == nil {
+			r.unset = make(map[string]bool)
+		}
+		r.unset[flag.Name] = true
+	}
+	return value, err
+}
+
+func (r *optionsResolver) resolve(ctx *kong.Context, flag *kong.Flag) (interface{}, error) {
+	name := strings.Split(flag.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return nil, nil
+	}
+	if err := r.load(ctx); err != nil {
+		return nil, err
+	}
+	raw, ok := r.options[name]
+	if !ok {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	if number, ok := value.(float64); ok && flag.Target.Type() == reflect.TypeOf(time.Duration(0)) {
+		// durations are dumped as nanoseconds
+		value = time.Duration(number).String()
+	}
+	return value, nil
+}
+
+func (r *optionsResolver) load(ctx *kong.Context) error {
+	if r.loaded {
+		return nil
+	}
+	r.loaded = true
+	var filename string
+	for _, flag := range ctx.Flags() {
+		if flag.Name == configFlag {
+			filename, _ = ctx.FlagValue(flag).(string)
+			break
+		}
+	}
+	if filename == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		// daemon config will be created on first run
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var content struct {
+		Run map[string]json.RawMessage `json:"run"`
+	}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return fmt.Errorf("parse %s: %w", filename, err)
+	}
+	r.options = content.Run
+	return nil
+}
@@ -3,16 +3,37 @@ package internal
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"time"
 )
 
+const (
+	DefaultBind     = "0.0.0.0:8686"
+	DefaultCertFile = "server.crt"
+	DefaultKeyFile  = "server.key"
+)
+
+// Empty settings could be defined in daemon config, otherwise defaults are used (see SetDefaults)
 type HttpServer struct {
 	GracefulShutdown time.Duration `name:"graceful-shutdown" env:"GRACEFUL_SHUTDOWN" help:"Interval before server shutdown" default:"15s" json:"graceful_shutdown"`
-	Bind             string        `name:"bind" env:"BIND" help:"Address to where bind HTTP server" default:"0.0.0.0:8686" json:"bind"`
+	Bind             string        `name:"bind" env:"BIND" help:"Address to where bind HTTP server (default 0.0.0.0:8686)" json:"bind"`
 	TLS              bool          `name:"tls" env:"TLS" help:"Enable HTTPS serving with TLS" json:"tls"`
-	CertFile         string        `name:"cert-file" env:"CERT_FILE" help:"Path to certificate for TLS" default:"server.crt" json:"crt_file"`
-	KeyFile          string        `name:"key-file" env:"KEY_FILE" help:"Path to private key for TLS" default:"server.key" json:"key_file"`
+	CertFile         string        `name:"cert-file" env:"CERT_FILE" help:"Path to certificate for TLS (default server.crt)" json:"crt_file"`
+	KeyFile          string        `name:"key-file" env:"KEY_FILE" help:"Path to private key for TLS (default server.key)" json:"key_file"`
+}
+
+// Fill empty settings by defaults
+func (qs *HttpServer) SetDefaults() {
+	if qs.Bind == "" {
+		qs.Bind = DefaultBind
+	}
+	if qs.CertFile == "" {
+		qs.CertFile = DefaultCertFile
+	}
+	if qs.KeyFile == "" {
+		qs.KeyFile = DefaultKeyFile
+	}
 }
 
 func (qs *HttpServer) Serve(globalCtx context.Context, handler http.Handler) error {
@@ -22,15 +43,30 @@ func (qs *HttpServer) Serve(globalCtx context.Context, handler http.Handler) err
 		Handler: handler,
 	}
 
+	qs.shutdownOnDone(globalCtx, &server)
+	log.Println("REST server is on", qs.Bind)
+	if qs.TLS {
+		return server.ListenAndServeTLS(qs.CertFile, qs.KeyFile)
+	}
+	return server.ListenAndServe()
+}
+
+// Serve handler on additional listener (ex: unix socket). Connection context could be enriched by connContext
+func (qs *HttpServer) ServeListener(globalCtx context.Context, listener net.Listener, handler http.Handler, connContext func(ctx context.Context, c net.Conn) context.Context) error {
+	server := http.Server{
+		Handler:     handler,
+		ConnContext: connContext,
+	}
+	qs.shutdownOnDone(globalCtx, &server)
+	log.Println("REST server is on", listener.Addr())
+	return server.Serve(listener)
+}
+
+func (qs *HttpServer) shutdownOnDone(globalCtx context.Context, server *http.Server) {
 	go func() {
 		<-globalCtx.Done()
 		ctx, cancel := context.WithTimeout(context.Background(), qs.GracefulShutdown)
 		defer cancel()
 		server.Shutdown(ctx)
 	}()
-	log.Println("REST server is on", qs.Bind)
-	if qs.TLS {
-		return server.ListenAndServeTLS(qs.CertFile, qs.KeyFile)
-	}
-	return server.ListenAndServe()
 }
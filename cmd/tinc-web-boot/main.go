@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/alecthomas/kong"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/tinc-boot/tincd/network"
 	"io/ioutil"
 	"log"
@@ -14,43 +13,64 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"os/user"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 	"tinc-web-boot/cmd/tinc-web-boot/internal"
 	"tinc-web-boot/pool"
 	"tinc-web-boot/web"
+	"tinc-web-boot/web/shared"
 )
 
 var version = "dev"
 
 const (
-	configFile = "tinc-web.json"
+	configFile = "tinc-web.json"  // daemon config name in data directory (and legacy location in working directory)
+	tokensFile = "tokens.json"    // registry of issued tokens in data directory
+	keysFile   = "auth-keys.json" // signing keys of tokens in data directory
 )
 
 type globalContext struct {
-	ctx context.Context
+	ctx     context.Context
+	options *optionsResolver // nil if options are not resolved
 }
 
 type Main struct {
-	Run     Root             `cmd:"run" default:"1" json:"run"`
-	New     create           `cmd:"new" help:"Create new network"  json:"-"`
-	Delete  remove           `cmd:"delete" help:"Delete network"  json:"-"`
-	Join    join             `cmd:"join" help:"Join by majordomo"  json:"-"`
-	Invite  invite           `cmd:"invite" help:"Invite people by link"  json:"-"`
-	List    listNetworks     `cmd:"list" help:"List networks"  json:"-"`
-	Info    getNetwork       `cmd:"info" help:"Get network info"  json:"-"`
-	Share   shareNetwork     `cmd:"share" help:"Share network"  json:"-"`
-	Import  importNetwork    `cmd:"import" help:"Import network"  json:"-"`
-	Start   start            `cmd:"start" help:"Start network"  json:"-"`
-	Stop    stop             `cmd:"stop" help:"Stop network"  json:"-"`
-	Peers   peers            `cmd:"peers" help:"List connected peers"  json:"-"`
-	Upgrade upgrade          `cmd:"upgrade" help:"Upgrade network"  json:"-"`
-	Version kong.VersionFlag `name:"version" help:"print version and exit"  json:"-"`
+	Run         Root             `cmd:"run" default:"1" json:"run"`
+	New         create           `cmd:"new" help:"Create new network"  json:"-"`
+	Rename      rename           `cmd:"rename" help:"Rename network"  json:"-"`
+	Clone       clone            `cmd:"clone" help:"Create network with settings of another network"  json:"-"`
+	Delete      remove           `cmd:"delete" help:"Delete network"  json:"-"`
+	Trash       trash            `cmd:"trash" help:"Manage removed networks"  json:"-"`
+	Template    template         `cmd:"template" help:"Manage templates for new networks"  json:"-"`
+	Join        join             `cmd:"join" help:"Join by majordomo"  json:"-"`
+	Invite      invite           `cmd:"invite" help:"Invite people by link"  json:"-"`
+	Invitations invitations      `cmd:"invitations" help:"Manage invitations to network"  json:"-"`
+	Requests    joinRequests     `cmd:"requests" help:"Manage requests to join network which wait for approval"  json:"-"`
+	List        listNetworks     `cmd:"list" help:"List networks"  json:"-"`
+	Info        getNetwork       `cmd:"info" help:"Get network info"  json:"-"`
+	Share       shareNetwork     `cmd:"share" help:"Share network"  json:"-"`
+	Import      importNetwork    `cmd:"import" help:"Import network"  json:"-"`
+	Backup      backup           `cmd:"backup" help:"Backup and restore networks"  json:"-"`
+	Start       start            `cmd:"start" help:"Start network"  json:"-"`
+	Stop        stop             `cmd:"stop" help:"Stop network"  json:"-"`
+	Status      status           `cmd:"status" help:"Show network runtime status"  json:"-"`
+	Peers       peers            `cmd:"peers" help:"List connected peers"  json:"-"`
+	Upgrade     upgrade          `cmd:"upgrade" help:"Upgrade network"  json:"-"`
+	Autostart   autostart        `cmd:"autostart" help:"Manage networks autostart"  json:"-"`
+	Lease       lease            `cmd:"lease" help:"Manage addresses leased to joined nodes"  json:"-"`
+	Config      config           `cmd:"config" help:"Manage configuration"  json:"-"`
+	Token       token            `cmd:"token" help:"Manage access tokens"  json:"-"`
+	RotateKey   rotateKey        `cmd:"rotate-key" help:"Generate new signing key for tokens"  json:"-"`
+	Version     kong.VersionFlag `name:"version" help:"print version and exit"  json:"-"`
 }
 
 type Root struct {
 	TincBin         string   `name:"tinc-bin" env:"TINC_BIN" help:"Custom tinc binary location" default:"tincd" json:"tinc_bin"`
 	Dir             string   `name:"dir" env:"DIR" help:"Directory for config" default:"networks" json:"dir"`
+	Config          string   `name:"config" env:"CONFIG" help:"Daemon config file, also options are loaded from its run section (default - tinc-web.json in --dir)" json:"-"`
 	Dev             bool     `name:"dev" env:"DEV" help:"Enable DEV mode (CORS + logging)" json:"-"`
 	Headless        bool     `long:"headless" env:"HEADLESS" description:"Disable launch browser" json:"-"`
 	DevGenOnly      bool     `name:"dev-gen-only" env:"DEV_GEN_ONLY" help:"(dev only) generate sample config but don't run" json:"-"`
@@ -61,14 +81,19 @@ type Root struct {
 	DevAutoStart    bool     `long:"dev-auto-start" env:"DEV_AUTO_START" description:"Enable dev network autostart"`
 	NoApp           bool     `name:"no-app" env:"NO_APP" help:"Don't try to open UI in application mode (if possible)" json:"no_app"`
 	UIPublicAddress []string `short:"A" name:"ui-public-address" env:"UI_PUBLIC_ADDRESS" help:"Custom UI public addresses (host:port) for links" json:"ui_public_addresses"`
-	AuthKey         string   `name:"auth-key" env:"AUTH_KEY" help:"JWT signing key (empty - autogenerated)" json:"auth_key"`
+	AuthKey         string   `name:"auth-key" env:"AUTH_KEY" help:"JWT signing key (empty - from key file or generated once in data directory)" json:"auth_key"`
+	AuthKeyFile     string   `name:"auth-key-file" env:"AUTH_KEY_FILE" help:"File with JWT signing key" json:"auth_key_file"`
+	Socket          string   `name:"socket" env:"SOCKET" help:"Unix socket for local API clients authorized by process credentials (empty - disabled)" default:"/var/run/tinc-web-boot.sock" json:"socket"`
+	SocketGroup     string   `name:"socket-group" env:"SOCKET_GROUP" help:"Group (name or ID) which members get admin access over unix socket" json:"socket_group"`
+	NoTokenPath     bool     `name:"no-token-path" env:"NO_TOKEN_PATH" help:"Accept tokens only from Authorization header or session cookie (disable legacy /api/<token>/ URLs)" json:"no_token_path"`
 	DumpKey         string   `short:"f" name:"dump-key" env:"DUMP_KEY" help:"Dump API token" default:".tinc-web-boot" json:"dump_key"`
 	internal.HttpServer
 }
 
 func main() {
 	var cli Main
-	ctx := kong.Parse(&cli, kong.Vars{"version": version})
+	options := &optionsResolver{}
+	ctx := kong.Parse(&cli, kong.Vars{"version": version}, kong.Resolvers(options))
 	gctx, closer := context.WithCancel(context.Background())
 	go func() {
 		c := make(chan os.Signal, 2)
@@ -79,7 +104,7 @@ func main() {
 		}
 	}()
 	defer closer()
-	err := ctx.Run(&globalContext{ctx: gctx})
+	err := ctx.Run(&globalContext{ctx: gctx, options: options})
 	ctx.FatalIfErrorf(err)
 }
 
@@ -88,7 +113,7 @@ func (cli Main) dumpConfig(filename string) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filename, data, 0755)
+	return ioutil.WriteFile(filename, data, 0600)
 }
 
 func (m *Root) Run(global *globalContext) error {
@@ -108,15 +133,37 @@ func (m *Root) Run(global *globalContext) error {
 	}
 	log.Println("preload complete")
 
-	networksPool, err := pool.New(global.ctx, configFile, m.Dir, binary)
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	daemonConfig := m.Config
+	if daemonConfig == "" {
+		daemonConfig = filepath.Join(m.Dir, configFile)
+	}
+	if err := moveLegacyConfig(daemonConfig); err != nil {
+		return fmt.Errorf("move legacy config: %w", err)
+	}
+
+	networksPool, err := pool.New(global.ctx, daemonConfig, m.Dir, binary)
 	if err != nil {
 		return err
 	}
 	defer networksPool.Stop()
 
+	isSet := func(flag string) bool { return false }
+	if global.options != nil {
+		isSet = global.options.IsSet
+	}
+	if err := m.applyDaemonConfig(networksPool.Config.Daemon, isSet); err != nil {
+		return err
+	}
+
 	networksPool.Events().Sink(func(eventName string, payload interface{}) {
 		log.Printf("[TRACE] (%s) %+v", eventName, payload)
 	})
+	networksPool.Lifecycle().Sink(func(eventName string, payload interface{}) {
+		log.Printf("[TRACE] (%s) %+v", eventName, payload)
+	})
 
 	if m.Dev {
 		_, subnet, err := net.ParseCIDR(m.DevSubnet)
@@ -142,9 +189,7 @@ func (m *Root) Run(global *globalContext) error {
 			return err
 		}
 		if m.DevAutoStart {
-			networksPool.Config.AutoStart.Set(ntw.Name())
-
-			err = networksPool.Config.Save()
+			err = networksPool.SetAutoStart(ntw.Name(), true)
 			if err != nil {
 				return err
 			}
@@ -157,20 +202,52 @@ func (m *Root) Run(global *globalContext) error {
 			return err
 		}
 	}
-	if m.AuthKey == "" {
-		m.AuthKey = uuid.New().String()
-	}
 	_, portStr, _ := net.SplitHostPort(m.Bind)
 	port, _ := strconv.Atoi(portStr)
 	apiCfg := web.Config{
 		Dev:             m.Dev,
 		AuthorizedOnly:  m.Headless,
 		AuthKey:         m.AuthKey,
+		TokensFile:      filepath.Join(m.Dir, tokensFile),
+		NoTokenPath:     m.NoTokenPath,
 		LocalUIPort:     uint16(port),
 		PublicAddresses: m.UIPublicAddress,
 		Binding:         m.Bind,
 	}
+	if m.AuthKey == "" {
+		apiCfg.Keyring, err = web.LoadKeyring(filepath.Join(m.Dir, keysFile))
+		if err != nil {
+			return fmt.Errorf("load signing keys: %w", err)
+		}
+	}
+	var socketGroup = -1
+	if m.SocketGroup != "" {
+		socketGroup, err = lookupGroup(m.SocketGroup)
+		if err != nil {
+			return err
+		}
+		apiCfg.SocketGroups = []int{socketGroup}
+	}
 	webApi, uiApp := apiCfg.New(networksPool)
+	if m.Socket != "" && web.SocketSupported {
+		listener, err := web.ListenSocket(m.Socket, socketGroup)
+		if err != nil {
+			log.Println("[WARN]", "unix socket API disabled:", err)
+		} else {
+			defer os.Remove(m.Socket)
+			go func() {
+				err := m.ServeListener(global.ctx, listener, webApi, web.ConnContext)
+				if err != nil && err != http.ErrServerClosed {
+					log.Println("[WARN]", "unix socket API stopped:", err)
+				}
+			}()
+		}
+	}
+	if code, err := uiApp.IssueLoginCode(); err != nil {
+		log.Println("[WARN]", "failed to issue login code:", err)
+	} else {
+		fmt.Println("LOGIN CODE (single use, valid for few minutes):", code)
+	}
 	if !m.Headless {
 		go func() {
 
@@ -193,9 +270,13 @@ func (m *Root) Run(global *globalContext) error {
 			}
 		}()
 	} else {
-		token, err := uiApp.IssueAccessToken(global.ctx, 3650)
-		if err != nil {
-			return fmt.Errorf("issue token: %w", err)
+		// token dumped by previous run is still valid while signing key is kept
+		token := m.dumpedToken()
+		if !uiApp.IsValidToken(token) {
+			token, err = uiApp.IssueToken(global.ctx, shared.RoleAdmin, "headless", 3650)
+			if err != nil {
+				return fmt.Errorf("issue token: %w", err)
+			}
 		}
 		fmt.Println("\n-------------\n\n", "TOKEN:", token, "\n\n-------------")
 		if m.DumpKey != "" {
@@ -208,6 +289,90 @@ func (m *Root) Run(global *globalContext) error {
 	return m.Serve(global.ctx, webApi)
 }
 
+func (m *Root) dumpedToken() string {
+	if m.DumpKey == "" {
+		return ""
+	}
+	data, err := ioutil.ReadFile(m.DumpKey)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// fill settings which are not set explicitly (isSet reports it by flag name) from daemon config and defaults
+func (m *Root) applyDaemonConfig(cfg pool.DaemonConfig, isSet func(flag string) bool) error {
+	if !isSet("ui-public-address") && len(cfg.UIPublicAddress) > 0 {
+		m.UIPublicAddress = cfg.UIPublicAddress
+	}
+	if !isSet("auth-key-file") && cfg.AuthKeyFile != "" {
+		m.AuthKeyFile = cfg.AuthKeyFile
+	}
+	if !isSet("bind") && cfg.Bind != "" {
+		m.Bind = cfg.Bind
+	}
+	if !isSet("tls") {
+		m.TLS = cfg.TLS
+	}
+	if !isSet("cert-file") && cfg.CertFile != "" {
+		m.CertFile = cfg.CertFile
+	}
+	if !isSet("key-file") && cfg.KeyFile != "" {
+		m.KeyFile = cfg.KeyFile
+	}
+	m.SetDefaults()
+	if m.AuthKey == "" && m.AuthKeyFile != "" {
+		data, err := ioutil.ReadFile(m.AuthKeyFile)
+		if err != nil {
+			return fmt.Errorf("read auth key: %w", err)
+		}
+		m.AuthKey = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// group ID by name or ID
+func lookupGroup(name string) (int, error) {
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		group, err = user.LookupGroupId(name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("lookup group %s: %w", name, err)
+	}
+	return strconv.Atoi(group.Gid)
+}
+
+// move daemon config from legacy location (working directory) if there is no config in new location
+func moveLegacyConfig(target string) error {
+	legacy, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if legacy == target {
+		return nil
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(target, data, 0600); err != nil {
+		return err
+	}
+	log.Println("config moved from", legacy, "to", target)
+	return os.Remove(legacy)
+}
+
 func isGuiAvailable(global context.Context, url string, timeout time.Duration) bool {
 	ctx, cancel := context.WithTimeout(global, timeout)
 	defer cancel()
@@ -19,6 +19,7 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/tinc-boot/tincd v0.0.0-20200519054535-e47aa8b1df51
 	github.com/tinylib/msgp v1.1.2
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 	golang.org/x/mod v0.3.0 // indirect
 	golang.org/x/net v0.0.0-20200425230154-ff2c4b7c35a0
 	golang.org/x/sys v0.0.0-20200413165638-669c56c373c4 // indirect
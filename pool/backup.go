@@ -0,0 +1,319 @@
+package pool
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	backupVersion      = 1
+	backupManifestFile = "manifest.json"
+	backupNetworksDir  = "networks"
+)
+
+// Description of backup archive (tar.gz with manifest and files of networks)
+type BackupManifest struct {
+	Version  int              `json:"version"`
+	Created  time.Time        `json:"created"`
+	Networks []*BackupNetwork `json:"networks"`
+}
+
+// Network saved in backup
+type BackupNetwork struct {
+	Name      string   `json:"name"`
+	Node      string   `json:"node"`   // self node name
+	Subnet    string   `json:"subnet"` // network subnet (CIDR)
+	Autostart bool     `json:"autostart"`
+	Files     []string `json:"files"` // files relative to network directory: keys, hosts, scripts, ...
+}
+
+// Options of restore from backup
+type RestoreOptions struct {
+	Networks     []string `json:"networks,omitempty"`      // restore only listed networks (empty - all)
+	SkipExisting bool     `json:"skip_existing,omitempty"` // do not touch existent networks (by default they are moved to trash and replaced)
+}
+
+// Result of restore from backup
+type RestoreResult struct {
+	Restored []string `json:"restored"`
+	Skipped  []string `json:"skipped,omitempty"`
+}
+
+// Write backup of all defined networks (files and autostart state) to archive
+func (pool *Pool) Backup(out io.Writer) (*BackupManifest, error) {
+	list, err := pool.Nets()
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BackupManifest{Version: backupVersion, Created: time.Now()}
+	for _, ntw := range list {
+		if !ntw.IsDefined() {
+			continue
+		}
+		self, err := ntw.Self()
+		if err != nil {
+			return nil, fmt.Errorf("read self node of %s: %w", ntw.Name(), err)
+		}
+		files, err := networkFiles(ntw)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Networks = append(manifest.Networks, &BackupNetwork{
+			Name:      ntw.Name(),
+			Node:      self.Name,
+			Subnet:    self.Subnet,
+			Autostart: pool.IsAutoStart(ntw.Name()),
+			Files:     files,
+		})
+	}
+
+	gz := gzip.NewWriter(out)
+	archive := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = archive.WriteHeader(&tar.Header{
+		Name:    backupManifestFile,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: manifest.Created,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := archive.Write(data); err != nil {
+		return nil, err
+	}
+	for _, item := range manifest.Networks {
+		root := filepath.Join(pool.rootDir, item.Name)
+		for _, file := range item.Files {
+			if err := addFile(archive, filepath.Join(root, filepath.FromSlash(file)), path.Join(backupNetworksDir, item.Name, file)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, gz.Close()
+}
+
+// Restore networks from backup archive. Existent networks are stopped, moved to trash and replaced
+// (or skipped if requested). Restored networks which were running before are started again
+func (pool *Pool) RestoreBackup(in io.Reader, options RestoreOptions) (*RestoreResult, error) {
+	staging, err := ioutil.TempDir(pool.rootDir, ".restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := extractBackup(in, staging)
+	if err != nil {
+		return nil, fmt.Errorf("read backup: %w", err)
+	}
+	var selected = make(map[string]*BackupNetwork)
+	for _, item := range manifest.Networks {
+		selected[item.Name] = item
+	}
+	if len(options.Networks) > 0 {
+		var filtered = make(map[string]*BackupNetwork)
+		for _, name := range options.Networks {
+			item, ok := selected[name]
+			if !ok {
+				return nil, fmt.Errorf("network %s not found in backup", name)
+			}
+			filtered[name] = item
+		}
+		selected = filtered
+	}
+
+	var result RestoreResult
+	var toRestore []*BackupNetwork
+	for _, item := range manifest.Networks {
+		if _, ok := selected[item.Name]; !ok {
+			continue
+		}
+		target, err := pool.Network(item.Name)
+		if err != nil {
+			return nil, err
+		}
+		if target.IsDefined() && options.SkipExisting {
+			result.Skipped = append(result.Skipped, item.Name)
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(item.Subnet)
+		if err != nil {
+			return nil, fmt.Errorf("parse subnet of %s: %w", item.Name, err)
+		}
+		pool.lock.Lock()
+		err = pool.checkSubnet(item.Name, subnet)
+		pool.lock.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("restore %s: %w", item.Name, err)
+		}
+		toRestore = append(toRestore, item)
+	}
+
+	for _, item := range toRestore {
+		running, err := pool.replaceNetwork(item.Name, filepath.Join(staging, item.Name))
+		if err != nil {
+			return &result, err
+		}
+		if err := pool.SetAutoStart(item.Name, item.Autostart); err != nil {
+			return &result, err
+		}
+		result.Restored = append(result.Restored, item.Name)
+		if running {
+			ntw, err := pool.Network(item.Name)
+			if err != nil {
+				return &result, err
+			}
+			if _, err := pool.RunNetwork(ntw); err != nil {
+				return &result, fmt.Errorf("start restored network %s: %w", item.Name, err)
+			}
+		}
+	}
+	return &result, nil
+}
+
+// stop network and move it to trash (if exists), then move restored files in place. Returns true if network was running
+func (pool *Pool) replaceNetwork(name string, source string) (bool, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	instance, running := pool.nets[name]
+	delete(pool.nets, name)
+	if running {
+		instance.Stop()
+		<-instance.Done()
+	}
+	ntw := &network.Network{Root: filepath.Join(pool.rootDir, name)}
+	return running, pool.watcher.change(func() error {
+		if _, err := pool.moveToTrash(name); err != nil {
+			return err
+		}
+		if err := os.Rename(source, ntw.Root); err != nil {
+			return fmt.Errorf("restore %s: %w", name, err)
+		}
+		return nil
+	}, ntw)
+}
+
+// regular files of network (except PID file) relative to network directory
+func networkFiles(ntw *network.Network) ([]string, error) {
+	var files []string
+	err := filepath.Walk(ntw.Root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || file == ntw.Pidfile() {
+			return nil
+		}
+		rel, err := filepath.Rel(ntw.Root, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+func addFile(archive *tar.Writer, file string, name string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(archive, f)
+	return err
+}
+
+// extract networks files to directory (each network to own sub-directory) and return manifest
+func extractBackup(in io.Reader, dir string) (*BackupManifest, error) {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	var manifest *BackupManifest
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Name == backupManifestFile {
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(archive).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("parse manifest: %w", err)
+			}
+			continue
+		}
+		parts := strings.SplitN(path.Clean(header.Name), "/", 3)
+		if len(parts) != 3 || parts[0] != backupNetworksDir || !network.IsValidName(parts[1]) || strings.HasPrefix(parts[2], "../") {
+			return nil, fmt.Errorf("unexpected file %s", header.Name)
+		}
+		target := filepath.Join(dir, parts[1], filepath.FromSlash(parts[2]))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(f, archive)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := network.ApplyOwnerOfSudoUser(target); err != nil {
+			return nil, err
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("manifest not found")
+	}
+	if manifest.Version > backupVersion {
+		return nil, fmt.Errorf("backup version %d is newer than supported %d", manifest.Version, backupVersion)
+	}
+	for _, item := range manifest.Networks {
+		if !network.IsValidName(item.Name) {
+			return nil, fmt.Errorf("invalid network name %s", item.Name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, item.Name, "tinc.conf")); err != nil {
+			return nil, fmt.Errorf("network %s: %w", item.Name, err)
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const versionTimeout = 5 * time.Second
+
+var versionPattern = regexp.MustCompile(`tinc(?:d)? version (\S+)`)
+
+// Detected tincd binary
+type TincBinary struct {
+	Path     string   `json:"path"`
+	Version  string   `json:"version,omitempty"`  // empty if version not detected
+	Error    string   `json:"error,omitempty"`    // version detection error
+	Default  bool     `json:"default"`            // used by default for all networks
+	Networks []string `json:"networks,omitempty"` // networks which are configured to use the binary
+}
+
+// Tincd binaries (default and custom per network) with detected versions
+func (pool *Pool) Binaries() []*TincBinary {
+	pool.lock.Lock()
+	custom := make(map[string]string, len(pool.Config.TincBin))
+	for name, bin := range pool.Config.TincBin {
+		custom[name] = bin
+	}
+	pool.lock.Unlock()
+
+	var index = map[string]*TincBinary{
+		pool.tincBin: {Path: pool.tincBin, Default: true},
+	}
+	for name, bin := range custom {
+		item, ok := index[bin]
+		if !ok {
+			item = &TincBinary{Path: bin}
+			index[bin] = item
+		}
+		item.Networks = append(item.Networks, name)
+	}
+	var ans = make([]*TincBinary, 0, len(index))
+	for _, item := range index {
+		version, err := pool.binaryVersion(item.Path)
+		if err != nil {
+			item.Error = err.Error()
+		}
+		item.Version = version
+		sort.Strings(item.Networks)
+		ans = append(ans, item)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].Default != ans[j].Default {
+			return ans[i].Default
+		}
+		return ans[i].Path < ans[j].Path
+	})
+	return ans
+}
+
+// Binary used for the network: custom (if defined) or default
+func (pool *Pool) binaryFor(name string) string {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.binaryForUnsafe(name)
+}
+
+func (pool *Pool) binaryForUnsafe(name string) string {
+	if bin, ok := pool.Config.TincBin[name]; ok && bin != "" {
+		return bin
+	}
+	return pool.tincBin
+}
+
+// cached version of binary
+func (pool *Pool) binaryVersion(bin string) (string, error) {
+	pool.versionsLock.Lock()
+	defer pool.versionsLock.Unlock()
+	if version, ok := pool.versions[bin]; ok {
+		return version, nil
+	}
+	version, err := DetectVersion(pool.ctx, bin)
+	if err != nil {
+		return "", err
+	}
+	if pool.versions == nil {
+		pool.versions = make(map[string]string)
+	}
+	pool.versions[bin] = version
+	return version, nil
+}
+
+// Detect tincd version by invoking binary with --version flag
+func DetectVersion(ctx context.Context, bin string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("run %s: %w", bin, err)
+	}
+	match := versionPattern.FindSubmatch(out)
+	if match == nil {
+		return "", fmt.Errorf("unknown version format of %s", bin)
+	}
+	return string(match[1]), nil
+}
@@ -3,17 +3,111 @@ package pool
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
+// Current version of config schema. Files with older version are migrated on load
+const ConfigVersion = 1
+
+// Migrations of raw config by source version: migration N converts version N to N+1
+var configMigrations = []func(raw map[string]json.RawMessage) error{
+	migrateLegacyConfig,
+}
+
 type Config struct {
-	AutoStart StringSet `json:"auto_start,omitempty"`
+	Version        int                      `json:"version"` // schema version
+	AutoStart      StringSet                `json:"auto_start,omitempty"`
+	DefaultRestart RestartPolicy            `json:"default_restart"`
+	Restart        map[string]RestartPolicy `json:"restart,omitempty"`         // custom restart policies per network
+	TrashRetention time.Duration            `json:"trash_retention,omitempty"` // how long removed networks are kept
+	Watch          WatchConfig              `json:"watch"`
+	TincBin        map[string]string        `json:"tinc_bin,omitempty"`  // custom tincd binaries per network
+	Templates      map[string]*Template     `json:"templates,omitempty"` // templates for new networks
+	IPAM           IPAMConfig               `json:"ipam"`                // automatic subnets allocation
+	Daemon         DaemonConfig             `json:"run"`                 // daemon settings (same layout as in dumped CLI config)
 
 	_filename string
 }
 
+// Settings of daemon. Used by run command if related flags are not set.
+// Other options of run command (loaded by --config flag) are kept as is
+type DaemonConfig struct {
+	UIPublicAddress []string `json:"ui_public_addresses,omitempty"` // public addresses (host:port) for links
+	AuthKeyFile     string   `json:"auth_key_file,omitempty"`       // file with JWT signing key
+	Bind            string   `json:"bind,omitempty"`                // binding address of HTTP server
+	TLS             bool     `json:"tls,omitempty"`                 // serve HTTPS
+	CertFile        string   `json:"crt_file,omitempty"`            // TLS certificate
+	KeyFile         string   `json:"key_file,omitempty"`            // TLS private key
+
+	options map[string]json.RawMessage
+}
+
+type daemonConfigFields DaemonConfig
+
+func (dc *DaemonConfig) UnmarshalJSON(data []byte) error {
+	var options map[string]json.RawMessage
+	if err := json.Unmarshal(data, &options); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*daemonConfigFields)(dc)); err != nil {
+		return err
+	}
+	for _, key := range jsonKeys(reflect.TypeOf(*dc)) {
+		delete(options, key)
+	}
+	dc.options = options
+	return nil
+}
+
+func (dc DaemonConfig) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(daemonConfigFields(dc))
+	if err != nil || len(dc.options) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range dc.options {
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
+
+// JSON names of exported struct fields
+func jsonKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
 func (cfg *Config) Filename() string { return cfg._filename }
 
+// Effective restart policy for network (custom or default) with filled defaults
+func (cfg *Config) RestartPolicy(network string) RestartPolicy {
+	if policy, ok := cfg.Restart[network]; ok {
+		return policy.Normalize()
+	}
+	return cfg.DefaultRestart.Normalize()
+}
+
 func (cfg *Config) Save() error {
 	return cfg.SaveAs(cfg._filename)
 }
@@ -22,12 +116,13 @@ func (cfg *Config) SaveAs(filename string) error {
 	if filename == "" {
 		return errors.New("file name not specified")
 	}
-	f, err := os.Create(filename)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
+	cfg.Version = ConfigVersion
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
 	err = enc.Encode(cfg)
@@ -42,32 +137,72 @@ func (cfg *Config) Load() error {
 	return cfg.LoadFrom(cfg._filename)
 }
 
+// Load config from file. Config with older schema is migrated, saved and original file is kept with .bak suffix
 func (cfg *Config) LoadFrom(filename string) error {
 	if filename == "" {
 		return errors.New("file name not specified")
 	}
-	f, err := os.Open(filename)
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	err = json.NewDecoder(f).Decode(cfg)
+	migrated, version, err := migrateConfig(data)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", filename, err)
+	}
+	err = json.Unmarshal(migrated, cfg)
 	if err != nil {
 		return err
 	}
-
 	cfg._filename = filename
+	if version == ConfigVersion {
+		return nil
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", filename, version)
+	if err := ioutil.WriteFile(backup, data, 0600); err != nil {
+		return fmt.Errorf("backup config before migration: %w", err)
+	}
+	log.Println("config", filename, "migrated from version", version, "to", ConfigVersion, "(original saved to", backup+")")
+	return cfg.Save()
+}
+
+// apply migrations to raw config and return result with source version
+func migrateConfig(data []byte) ([]byte, int, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	var version int
+	if v, ok := raw["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, 0, fmt.Errorf("parse version: %w", err)
+		}
+	}
+	if version > ConfigVersion {
+		return nil, version, fmt.Errorf("config version %d is newer than supported %d", version, ConfigVersion)
+	}
+	if version == ConfigVersion {
+		return data, version, nil
+	}
+	for _, migration := range configMigrations[version:] {
+		if err := migration(raw); err != nil {
+			return nil, version, err
+		}
+	}
+	raw["version"], _ = json.Marshal(ConfigVersion)
+	data, err := json.Marshal(raw)
+	return data, version, err
+}
+
+// legacy (version-less) config has same layout as version 1, only version is stamped
+func migrateLegacyConfig(raw map[string]json.RawMessage) error {
 	return nil
 }
 
 type StringSet map[string]bool
 
 func (s *StringSet) MarshalJSON() ([]byte, error) {
-	var keys = make([]string, 0, len(*s))
-	for k := range *s {
-		keys = append(keys, k)
-	}
-	return json.Marshal(keys)
+	return json.Marshal(s.List())
 }
 
 func (s *StringSet) UnmarshalJSON(bytes []byte) error {
@@ -77,7 +212,7 @@ func (s *StringSet) UnmarshalJSON(bytes []byte) error {
 		return err
 	}
 	for _, k := range keys {
-		(*s)[k] = true
+		s.Set(k)
 	}
 	return nil
 }
@@ -85,9 +220,22 @@ func (s *StringSet) UnmarshalJSON(bytes []byte) error {
 func (s *StringSet) Has(key string) bool { return (*s)[key] }
 
 func (s *StringSet) Set(key string) {
+	if *s == nil {
+		*s = make(StringSet)
+	}
 	(*s)[key] = true
 }
 
 func (s *StringSet) Del(key string) {
 	delete(*s, key)
 }
+
+// Sorted list of keys
+func (s *StringSet) List() []string {
+	var keys = make([]string, 0, len(*s))
+	for k := range *s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
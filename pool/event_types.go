@@ -0,0 +1,35 @@
+package pool
+
+import "time"
+
+//go:generate events-gen -p pool -E Lifecycle -s -P -o events.go -e Emitter
+
+//event:"Restarting"
+type Restarting struct {
+	Network string        `json:"network"`
+	Attempt int           `json:"attempt"`          // number of restart attempt (starts from 1)
+	Delay   time.Duration `json:"delay"`            // delay before restart
+	Reason  string        `json:"reason,omitempty"` // last exit error
+}
+
+//event:"GaveUp"
+type GaveUp struct {
+	Network  string `json:"network"`
+	Restarts int    `json:"restarts"`         // number of restarts made before give up
+	Reason   string `json:"reason,omitempty"` // last exit error
+}
+
+//event:"NetworkAdded"
+//event:"NetworkRemoved"
+type NetworkChanged struct {
+	Network string `json:"network"`
+}
+
+//event:"HostsChanged"
+type HostsChanged struct {
+	Network  string   `json:"network"`
+	Added    []string `json:"added,omitempty"`   // new host files
+	Removed  []string `json:"removed,omitempty"` // removed host files
+	Updated  []string `json:"updated,omitempty"` // changed host files
+	Reloaded bool     `json:"reloaded"`          // running tincd reloaded
+}
@@ -0,0 +1,157 @@
+package pool
+
+import "sync"
+
+type eventRestarting struct {
+	lock     sync.RWMutex
+	handlers []func(Restarting)
+}
+
+func (ev *eventRestarting) Subscribe(handler func(Restarting)) {
+	ev.lock.Lock()
+	ev.handlers = append(ev.handlers, handler)
+	ev.lock.Unlock()
+}
+func (ev *eventRestarting) Emit(payload Restarting) {
+	ev.lock.RLock()
+	for _, handler := range ev.handlers {
+		handler(payload)
+	}
+	ev.lock.RUnlock()
+}
+
+type eventGaveUp struct {
+	lock     sync.RWMutex
+	handlers []func(GaveUp)
+}
+
+func (ev *eventGaveUp) Subscribe(handler func(GaveUp)) {
+	ev.lock.Lock()
+	ev.handlers = append(ev.handlers, handler)
+	ev.lock.Unlock()
+}
+func (ev *eventGaveUp) Emit(payload GaveUp) {
+	ev.lock.RLock()
+	for _, handler := range ev.handlers {
+		handler(payload)
+	}
+	ev.lock.RUnlock()
+}
+
+type eventNetworkAdded struct {
+	lock     sync.RWMutex
+	handlers []func(NetworkChanged)
+}
+
+func (ev *eventNetworkAdded) Subscribe(handler func(NetworkChanged)) {
+	ev.lock.Lock()
+	ev.handlers = append(ev.handlers, handler)
+	ev.lock.Unlock()
+}
+func (ev *eventNetworkAdded) Emit(payload NetworkChanged) {
+	ev.lock.RLock()
+	for _, handler := range ev.handlers {
+		handler(payload)
+	}
+	ev.lock.RUnlock()
+}
+
+type eventNetworkRemoved struct {
+	lock     sync.RWMutex
+	handlers []func(NetworkChanged)
+}
+
+func (ev *eventNetworkRemoved) Subscribe(handler func(NetworkChanged)) {
+	ev.lock.Lock()
+	ev.handlers = append(ev.handlers, handler)
+	ev.lock.Unlock()
+}
+func (ev *eventNetworkRemoved) Emit(payload NetworkChanged) {
+	ev.lock.RLock()
+	for _, handler := range ev.handlers {
+		handler(payload)
+	}
+	ev.lock.RUnlock()
+}
+
+type eventHostsChanged struct {
+	lock     sync.RWMutex
+	handlers []func(HostsChanged)
+}
+
+func (ev *eventHostsChanged) Subscribe(handler func(HostsChanged)) {
+	ev.lock.Lock()
+	ev.handlers = append(ev.handlers, handler)
+	ev.lock.Unlock()
+}
+func (ev *eventHostsChanged) Emit(payload HostsChanged) {
+	ev.lock.RLock()
+	for _, handler := range ev.handlers {
+		handler(payload)
+	}
+	ev.lock.RUnlock()
+}
+
+type Lifecycle struct {
+	Restarting     eventRestarting
+	GaveUp         eventGaveUp
+	NetworkAdded   eventNetworkAdded
+	NetworkRemoved eventNetworkRemoved
+	HostsChanged   eventHostsChanged
+}
+
+func (bus *Lifecycle) Sink(sink func(eventName string, payload interface{})) *Lifecycle {
+	bus.Restarting.Subscribe(func(payload Restarting) {
+		sink("Restarting", payload)
+	})
+	bus.GaveUp.Subscribe(func(payload GaveUp) {
+		sink("GaveUp", payload)
+	})
+	bus.NetworkAdded.Subscribe(func(payload NetworkChanged) {
+		sink("NetworkAdded", payload)
+	})
+	bus.NetworkRemoved.Subscribe(func(payload NetworkChanged) {
+		sink("NetworkRemoved", payload)
+	})
+	bus.HostsChanged.Subscribe(func(payload HostsChanged) {
+		sink("HostsChanged", payload)
+	})
+	return bus
+}
+func (bus *Lifecycle) Emitter() *emitterLifecycle {
+	return &emitterLifecycle{events: bus}
+}
+
+type emitterLifecycle struct {
+	events *Lifecycle
+}
+
+func (emitter *emitterLifecycle) Restarting(payload Restarting) {
+	emitter.events.Restarting.Emit(payload)
+}
+func (emitter *emitterLifecycle) GaveUp(payload GaveUp) {
+	emitter.events.GaveUp.Emit(payload)
+}
+func (emitter *emitterLifecycle) NetworkAdded(payload NetworkChanged) {
+	emitter.events.NetworkAdded.Emit(payload)
+}
+func (emitter *emitterLifecycle) NetworkRemoved(payload NetworkChanged) {
+	emitter.events.NetworkRemoved.Emit(payload)
+}
+func (emitter *emitterLifecycle) HostsChanged(payload HostsChanged) {
+	emitter.events.HostsChanged.Emit(payload)
+}
+
+func (bus *Lifecycle) SubscribeAll(listener interface {
+	Restarting(payload Restarting)
+	GaveUp(payload GaveUp)
+	NetworkAdded(payload NetworkChanged)
+	NetworkRemoved(payload NetworkChanged)
+	HostsChanged(payload HostsChanged)
+}) {
+	bus.Restarting.Subscribe(listener.Restarting)
+	bus.GaveUp.Subscribe(listener.GaveUp)
+	bus.NetworkAdded.Subscribe(listener.NetworkAdded)
+	bus.NetworkRemoved.Subscribe(listener.NetworkRemoved)
+	bus.HostsChanged.Subscribe(listener.HostsChanged)
+}
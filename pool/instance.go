@@ -0,0 +1,250 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/reddec/jsonrpc2"
+	"github.com/reddec/jsonrpc2/client"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"github.com/tinc-boot/tincd/runner"
+	"log"
+	"net"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Running tincd with tinc-web-boot protocol (greeting of nodes), same as instance from tincd library,
+// but binary is defined explicitly instead of lookup in PATH
+type instance struct {
+	tincBin     string
+	activePeers sync.Map
+	events      network.Events
+	definition  *network.Network
+
+	stop func()
+	done chan struct{}
+	err  error
+}
+
+// Start tincd by binary (path or name in PATH). Not blocking after start
+func startTincd(ctx context.Context, ntw *network.Network, bin string) (tincd.Tincd, error) {
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", ntw.Name())
+	}
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, fmt.Errorf("detect tinc binary: %w", err)
+	}
+	impl := &instance{
+		definition: ntw,
+		tincBin:    path,
+	}
+	return impl, impl.start(ctx)
+}
+
+func (impl *instance) start(global context.Context) error {
+	if err := impl.definition.Prepare(global, impl.tincBin); err != nil {
+		return fmt.Errorf("configure: %w", err)
+	}
+	self, _, err := impl.definition.SelfConfig()
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(impl.definition.Root)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(global)
+	impl.stop = cancel
+	impl.done = make(chan struct{})
+	go func() {
+		defer cancel()
+		defer impl.events.Stopped.Emit(network.NetworkID{Name: impl.definition.Name()})
+		defer close(impl.done)
+		impl.err = impl.run(ctx, absDir, self)
+		impl.activePeers = sync.Map{}
+	}()
+	return nil
+}
+
+func (impl *instance) Events() *network.Events { return &impl.events }
+
+func (impl *instance) Stop() { impl.stop() }
+
+func (impl *instance) Done() <-chan struct{} { return impl.done }
+
+func (impl *instance) Error() error { return impl.err }
+
+func (impl *instance) Definition() *network.Network { return impl.definition }
+
+func (impl *instance) Peers() []string {
+	var ans []string
+	impl.activePeers.Range(func(key, value interface{}) bool {
+		ans = append(ans, key.(string))
+		return true
+	})
+	sort.Strings(ans)
+	return ans
+}
+
+func (impl *instance) IsActive(node string) bool {
+	_, ok := impl.activePeers.Load(node)
+	return ok
+}
+
+func (impl *instance) IsRunning() bool {
+	select {
+	case <-impl.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (impl *instance) run(global context.Context, absDir string, self *network.Node) error {
+	ctx, abort := context.WithCancel(global)
+	defer abort()
+
+	var wg sync.WaitGroup
+
+	// run tinc service
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer abort()
+		for event := range runner.RunTinc(global, false, impl.tincBin, absDir) {
+			if event.Add {
+				impl.activePeers.Store(event.Peer.Node, event)
+			} else {
+				impl.activePeers.Delete(event.Peer.Node)
+			}
+			log.Printf("%+v", event)
+		}
+	}()
+
+	// run tinc-web-boot protocol API
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer abort()
+		for {
+			err := impl.serveAPI(ctx, self.IP+":"+strconv.Itoa(tincd.CommunicationPort))
+			log.Println(impl.definition.Name(), "api stopped:", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+				log.Println("trying again...")
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		impl.greetEveryone(ctx, *self, tincd.GreetInterval)
+	}()
+
+	// change owner of pid file to process runner
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-time.After(2 * time.Second):
+			_ = network.ApplyOwnerOfSudoUser(impl.definition.Pidfile())
+		}
+	}()
+
+	impl.activePeers.Store(self.Name, self)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// serve API.Exchange method: save remote node and return all known nodes
+func (impl *instance) serveAPI(ctx context.Context, binding string) error {
+	listener, err := net.Listen("tcp", binding)
+	if err != nil {
+		return err
+	}
+	var router jsonrpc2.Router
+	router.RegisterFunc("API.Exchange", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Self network.Node `json:"self"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Self)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := impl.definition.Put(&args.Self); err != nil {
+			return nil, err
+		}
+		return impl.definition.NodesDefinitions()
+	})
+	server := http.Server{Handler: jsonrpc2.HandlerRest(&router)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	return server.Serve(listener)
+}
+
+// send self node to all known nodes and import nodes known by them
+func (impl *instance) greetEveryone(ctx context.Context, self network.Node, retryInterval time.Duration) {
+	nodes, err := impl.definition.NodesDefinitions()
+	if err != nil {
+		log.Println("greeting failed:", err)
+		return
+	}
+	var (
+		wg       sync.WaitGroup
+		sequence uint64
+	)
+	for _, node := range nodes {
+		if node.IP == "" {
+			log.Println("will not greet", node.Name, "'cause it is relay node")
+			continue
+		}
+		wg.Add(1)
+		go func(node network.Node) {
+			defer wg.Done()
+			url := "http://" + node.IP + ":" + strconv.Itoa(tincd.CommunicationPort)
+			for {
+				var toImport []network.Node
+				err := client.CallHTTP(ctx, url, "API.Exchange", atomic.AddUint64(&sequence, 1), &toImport, self)
+				if err == nil {
+					for _, remote := range toImport {
+						if err := impl.definition.Put(&remote); err != nil {
+							log.Println(node.Name, "import", remote.Name, ":", err)
+						}
+					}
+					log.Println("greeted", node.Name)
+					return
+				}
+				log.Println("greet", node.Name, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(retryInterval):
+				}
+			}
+		}(node)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,196 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const invitationsFile = "invitations.json"
+
+// Invitation could not be used: it is unknown, revoked, expired, used or issued for another node
+var ErrInvitationNotAccepted = errors.New("invitation is not accepted")
+
+// Invitation to join network (majordomo link). Invitation ID is same as ID of link token
+type Invitation struct {
+	ID          string        `json:"id"`
+	NodeName    string        `json:"node_name,omitempty"` // only node with this name could join (empty - any)
+	MaxUses     int           `json:"max_uses,omitempty"`  // maximum number of joins (0 - unlimited)
+	Approval    bool          `json:"approval,omitempty"`  // joins should be approved by admin (see JoinRequest)
+	Created     time.Time     `json:"created"`
+	Expires     time.Time     `json:"expires"`
+	Revoked     bool          `json:"revoked,omitempty"`
+	Redemptions []*Redemption `json:"redemptions,omitempty"`
+}
+
+// Join by invitation
+type Redemption struct {
+	Node    string    `json:"node"`
+	Address string    `json:"address,omitempty"` // remote address of joined node
+	Time    time.Time `json:"time"`
+}
+
+// Invitation could be used for joining
+func (inv *Invitation) IsActive() bool {
+	return !inv.Revoked && time.Now().Before(inv.Expires) && (inv.MaxUses <= 0 || len(inv.Redemptions) < inv.MaxUses)
+}
+
+// Store new invitation to network
+func (pool *Pool) CreateInvitation(name string, inv Invitation) (*Invitation, error) {
+	if inv.ID == "" {
+		return nil, fmt.Errorf("invitation ID is not defined")
+	}
+	if inv.NodeName != "" && !network.IsValidNodeName(inv.NodeName) {
+		return nil, fmt.Errorf("invalid node name %s", inv.NodeName)
+	}
+	if inv.MaxUses < 0 {
+		return nil, fmt.Errorf("maximum number of uses should not be negative")
+	}
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", name)
+	}
+	if inv.Created.IsZero() {
+		inv.Created = time.Now()
+	}
+	inv.Revoked = false
+	inv.Redemptions = nil
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	list, err := readInvitations(ntw)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		if item.ID == inv.ID {
+			return nil, fmt.Errorf("invitation %s already exists", inv.ID)
+		}
+	}
+	list = append(list, &inv)
+	return &inv, writeInvitations(ntw, list)
+}
+
+// Invitations to network sorted by creation time
+func (pool *Pool) Invitations(name string) ([]*Invitation, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	return readInvitations(ntw)
+}
+
+// Invitation to network by ID
+func (pool *Pool) Invitation(name, id string) (*Invitation, error) {
+	list, err := pool.Invitations(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("invitation %s not found in network %s", id, name)
+}
+
+// Revoke invitation. Record is kept to show redemptions. Returns false if invitation is unknown or already revoked
+func (pool *Pool) RevokeInvitation(name, id string) (bool, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return false, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	list, err := readInvitations(ntw)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if item.ID == id && !item.Revoked {
+			item.Revoked = true
+			return true, writeInvitations(ntw, list)
+		}
+	}
+	return false, nil
+}
+
+// Use invitation for joining node. Join is executed only if invitation is active and node name matched,
+// redemption is recorded only if join succeeded
+func (pool *Pool) RedeemInvitation(name, id string, redemption Redemption, join func() error) (*Invitation, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	list, err := readInvitations(ntw)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := acceptInvitation(list, id, redemption.Node)
+	if err != nil {
+		return nil, err
+	}
+	if err := join(); err != nil {
+		return nil, err
+	}
+	if redemption.Time.IsZero() {
+		redemption.Time = time.Now()
+	}
+	inv.Redemptions = append(inv.Redemptions, &redemption)
+	return inv, writeInvitations(ntw, list)
+}
+
+// find invitation which could be used by node
+func acceptInvitation(list []*Invitation, id, node string) (*Invitation, error) {
+	for _, inv := range list {
+		if inv.ID != id {
+			continue
+		}
+		if !inv.IsActive() {
+			return nil, fmt.Errorf("%w: revoked, expired or used", ErrInvitationNotAccepted)
+		}
+		if inv.NodeName != "" && inv.NodeName != node {
+			return nil, fmt.Errorf("%w: issued for node %s", ErrInvitationNotAccepted, inv.NodeName)
+		}
+		return inv, nil
+	}
+	return nil, fmt.Errorf("%w: not found", ErrInvitationNotAccepted)
+}
+
+func readInvitations(ntw *network.Network) ([]*Invitation, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ntw.Root, invitationsFile))
+	if os.IsNotExist(err) {
+		return []*Invitation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Invitation
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse invitations of %s: %w", ntw.Name(), err)
+	}
+	return list, nil
+}
+
+func writeInvitations(ntw *network.Network, list []*Invitation) error {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ntw.Root, invitationsFile), data, 0600)
+}
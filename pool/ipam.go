@@ -0,0 +1,159 @@
+package pool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+)
+
+const defaultIPAMPrefix = 16
+
+var defaultIPAMRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// Automatic allocation of subnets for new networks
+type IPAMConfig struct {
+	Ranges []string `json:"ranges,omitempty"` // private ranges (CIDR) to allocate subnets from
+	Prefix int      `json:"prefix,omitempty"` // prefix length of allocated subnets
+}
+
+// subnet which is already used by managed network or host route
+type usedSubnet struct {
+	subnet *net.IPNet
+	owner  string
+	iface  string // interface of host route
+}
+
+// Check that subnet is not overlapped with subnets of other networks and host routes (except routes of own interface).
+// Should be called under lock
+func (pool *Pool) checkSubnet(name string, subnet *net.IPNet) error {
+	used, err := pool.usedSubnets(name)
+	if err != nil {
+		return err
+	}
+	for _, item := range used {
+		if overlaps(subnet, item.subnet) {
+			return fmt.Errorf("subnet %s overlaps with %s (%s)", subnet, item.owner, item.subnet)
+		}
+	}
+	return nil
+}
+
+// Allocate free subnet from configured ranges. Should be called under lock
+func (pool *Pool) allocateSubnet(name string) (*net.IPNet, error) {
+	used, err := pool.usedSubnets(name)
+	if err != nil {
+		return nil, err
+	}
+	ranges := pool.Config.IPAM.Ranges
+	if len(ranges) == 0 {
+		ranges = defaultIPAMRanges
+	}
+	prefix := pool.Config.IPAM.Prefix
+	if prefix == 0 {
+		prefix = defaultIPAMPrefix
+	}
+	if prefix < 1 || prefix > 30 {
+		return nil, fmt.Errorf("invalid IPAM prefix length %d", prefix)
+	}
+	for _, cidr := range ranges {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parse IPAM range: %w", err)
+		}
+		ones, bits := block.Mask.Size()
+		if bits != 32 || ones > prefix {
+			continue
+		}
+		base := binary.BigEndian.Uint32(block.IP.To4())
+		count := uint32(1) << uint(prefix-ones)
+		// start from random candidate to reduce chance of conflicts with networks of other nodes
+		offset := uint32(rand.Int63n(int64(count)))
+		for i := uint32(0); i < count; i++ {
+			var ip = make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, base+((offset+i)%count)<<uint(32-prefix))
+			candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(prefix, 32)}
+			if !overlapsAny(candidate, used) {
+				return candidate, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no free subnets in IPAM ranges")
+}
+
+// subnets of managed networks (except the named one) and host routes
+func (pool *Pool) usedSubnets(exclude string) ([]usedSubnet, error) {
+	list, err := pool.Nets()
+	if err != nil {
+		return nil, err
+	}
+	var ans []usedSubnet
+	var excludeIface string
+	for _, ntw := range list {
+		if ntw.Name() == exclude && ntw.IsDefined() {
+			// routes of own interface are replaced together with network
+			if config, err := ntw.Read(); err == nil {
+				excludeIface = config.Interface
+			}
+		}
+		if ntw.Name() == exclude || !ntw.IsDefined() {
+			continue
+		}
+		self, err := ntw.Self()
+		if err != nil {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(self.Subnet)
+		if err != nil {
+			continue
+		}
+		ans = append(ans, usedSubnet{subnet: subnet, owner: "network " + ntw.Name()})
+	}
+	for _, route := range hostRoutes() {
+		if excludeIface != "" && route.iface == excludeIface {
+			continue
+		}
+		ans = append(ans, route)
+	}
+	return ans, nil
+}
+
+// routes defined by addresses of network interfaces
+func interfaceRoutes() []usedSubnet {
+	list, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var ans []usedSubnet
+	for _, iface := range list {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || ipNet.IP.IsLoopback() {
+				continue
+			}
+			ans = append(ans, usedSubnet{
+				subnet: &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask},
+				owner:  "host route via " + iface.Name,
+				iface:  iface.Name,
+			})
+		}
+	}
+	return ans
+}
+
+func overlapsAny(subnet *net.IPNet, used []usedSubnet) bool {
+	for _, item := range used {
+		if overlaps(subnet, item.subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
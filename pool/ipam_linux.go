@@ -0,0 +1,54 @@
+package pool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// routes from kernel routing table (except default route) and interfaces
+func hostRoutes() []usedSubnet {
+	ans := interfaceRoutes()
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return ans
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		destination, err1 := parseRouteHex(fields[1])
+		mask, err2 := parseRouteHex(fields[7])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		subnet := &net.IPNet{IP: destination, Mask: net.IPMask(mask)}
+		if ones, _ := subnet.Mask.Size(); ones == 0 {
+			continue
+		}
+		ans = append(ans, usedSubnet{subnet: subnet, owner: "host route via " + fields[0], iface: fields[0]})
+	}
+	return ans
+}
+
+// little-endian hex IPv4 as in /proc/net/route
+func parseRouteHex(value string) (net.IP, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != net.IPv4len {
+		return nil, fmt.Errorf("invalid IPv4 length")
+	}
+	var ip = make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(data))
+	return ip, nil
+}
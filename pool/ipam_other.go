@@ -0,0 +1,8 @@
+// +build !linux
+
+package pool
+
+// routes defined by interfaces
+func hostRoutes() []usedSubnet {
+	return interfaceRoutes()
+}
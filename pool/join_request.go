@@ -0,0 +1,193 @@
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	joinRequestsFile = "join-requests.json"
+	maxPendingJoins  = 16 // pending requests per invitation
+)
+
+type JoinStatus string
+
+const (
+	JoinPending  JoinStatus = "pending"
+	JoinApproved JoinStatus = "approved"
+	JoinRejected JoinStatus = "rejected"
+)
+
+// Request to join network by invitation which requires approval. Request is kept until node joined
+// or invitation expired
+type JoinRequest struct {
+	ID         string        `json:"id"`
+	Invitation string        `json:"invitation"`
+	Node       *network.Node `json:"node"`
+	Address    string        `json:"address,omitempty"` // remote address of joining node
+	Status     JoinStatus    `json:"status"`
+	Created    time.Time     `json:"created"`
+	Expires    time.Time     `json:"expires"`
+}
+
+// Find request of node to join by invitation or create new pending request. Returns true if request is created.
+// Node should keep same public key while request is not completed
+func (pool *Pool) RequestJoin(name, invitation string, node *network.Node, address string) (*JoinRequest, bool, error) {
+	if node == nil || !network.IsValidNodeName(node.Name) {
+		return nil, false, fmt.Errorf("invalid node")
+	}
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, false, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	invitations, err := readInvitations(ntw)
+	if err != nil {
+		return nil, false, err
+	}
+	inv, err := acceptInvitation(invitations, invitation, node.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	list, err := readJoinRequests(ntw)
+	if err != nil {
+		return nil, false, err
+	}
+	var pending int
+	for _, item := range list {
+		if item.Invitation != invitation {
+			continue
+		}
+		if item.Node.Name == node.Name {
+			if item.Node.PublicKey != node.PublicKey {
+				return nil, false, fmt.Errorf("another request of node %s is not completed", node.Name)
+			}
+			return item, false, nil
+		}
+		if item.Status == JoinPending {
+			pending++
+		}
+	}
+	if pending >= maxPendingJoins {
+		return nil, false, fmt.Errorf("too many pending requests by invitation")
+	}
+	req := &JoinRequest{
+		ID:         uuid.New().String(),
+		Invitation: invitation,
+		Node:       node,
+		Address:    address,
+		Status:     JoinPending,
+		Created:    time.Now(),
+		Expires:    inv.Expires,
+	}
+	list = append(list, req)
+	return req, true, writeJoinRequests(ntw, list)
+}
+
+// Not expired join requests sorted by creation time
+func (pool *Pool) JoinRequests(name string) ([]*JoinRequest, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	return readJoinRequests(ntw)
+}
+
+// Approve pending join request. Node joins network on next request
+func (pool *Pool) ApproveJoin(name, id string) (*JoinRequest, error) {
+	return pool.decideJoin(name, id, JoinApproved)
+}
+
+// Reject pending join request. Rejected request is kept till invitation expiration, so node could not repeat it
+func (pool *Pool) RejectJoin(name, id string) (*JoinRequest, error) {
+	return pool.decideJoin(name, id, JoinRejected)
+}
+
+// Remove request of joined node
+func (pool *Pool) CompleteJoin(name, id string) error {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	list, err := readJoinRequests(ntw)
+	if err != nil {
+		return err
+	}
+	var kept = list[:0]
+	for _, item := range list {
+		if item.ID != id {
+			kept = append(kept, item)
+		}
+	}
+	return writeJoinRequests(ntw, kept)
+}
+
+func (pool *Pool) decideJoin(name, id string, status JoinStatus) (*JoinRequest, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.invitationsLock.Lock()
+	defer pool.invitationsLock.Unlock()
+	list, err := readJoinRequests(ntw)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		if item.ID != id {
+			continue
+		}
+		if item.Status != JoinPending {
+			return nil, fmt.Errorf("join request %s is already %s", id, item.Status)
+		}
+		item.Status = status
+		return item, writeJoinRequests(ntw, list)
+	}
+	return nil, fmt.Errorf("join request %s not found in network %s", id, name)
+}
+
+// read not expired requests
+func readJoinRequests(ntw *network.Network) ([]*JoinRequest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ntw.Root, joinRequestsFile))
+	if os.IsNotExist(err) {
+		return []*JoinRequest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*JoinRequest
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse join requests of %s: %w", ntw.Name(), err)
+	}
+	var active = make([]*JoinRequest, 0, len(list))
+	now := time.Now()
+	for _, item := range list {
+		if now.Before(item.Expires) {
+			active = append(active, item)
+		}
+	}
+	return active, nil
+}
+
+func writeJoinRequests(ntw *network.Network, list []*JoinRequest) error {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ntw.Root, joinRequestsFile), data, 0600)
+}
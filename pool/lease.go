@@ -0,0 +1,306 @@
+package pool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const leasesFile = "leases.json"
+
+// VPN address leased to node by address authority (node that invites others)
+type Lease struct {
+	Node    string    `json:"node"`
+	IP      string    `json:"ip"`
+	Updated time.Time `json:"updated"`
+}
+
+// Leases of addresses in network sorted by node name
+func (pool *Pool) Leases(name string) ([]*Lease, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.leasesLock.Lock()
+	defer pool.leasesLock.Unlock()
+	return readLeases(ntw)
+}
+
+// Set (reserve) address for node. Address should be inside network subnet and not used by other nodes.
+// Node receives address on next join
+func (pool *Pool) SetLease(name, node, ip string) (*Lease, error) {
+	if !network.IsValidNodeName(node) {
+		return nil, fmt.Errorf("invalid node name")
+	}
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	addr := net.ParseIP(ip).To4()
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IPv4 address %s", ip)
+	}
+	pool.leasesLock.Lock()
+	defer pool.leasesLock.Unlock()
+	book, err := loadAddressBook(ntw)
+	if err != nil {
+		return nil, err
+	}
+	if node == book.self.Name {
+		return nil, fmt.Errorf("address of self node could not be leased")
+	}
+	if err := book.check(node, addr); err != nil {
+		return nil, err
+	}
+	return book.lease(node, addr)
+}
+
+// Remove lease of node. Returns true if lease existed
+func (pool *Pool) RemoveLease(name, node string) (bool, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return false, err
+	}
+	pool.leasesLock.Lock()
+	defer pool.leasesLock.Unlock()
+	list, err := readLeases(ntw)
+	if err != nil {
+		return false, err
+	}
+	for i, item := range list {
+		if item.Node == node {
+			return true, writeLeases(ntw, append(list[:i], list[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// Assign address to joining node and record lease. Existent lease or address requested by node (if it's free)
+// are preferred, otherwise first free address in network subnet is allocated.
+// Returns copy of node with subnet and IP of the network (version is increased if anything changed)
+func (pool *Pool) Assign(name string, node *network.Node) (*network.Node, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.leasesLock.Lock()
+	defer pool.leasesLock.Unlock()
+	book, err := loadAddressBook(ntw)
+	if err != nil {
+		return nil, err
+	}
+	if node.Name == book.self.Name {
+		return nil, fmt.Errorf("node name %s is same as name of self node", node.Name)
+	}
+	var addr net.IP
+	if lease, ok := book.leases[node.Name]; ok {
+		addr = net.ParseIP(lease.IP).To4()
+	}
+	if addr == nil {
+		if requested := net.ParseIP(node.IP).To4(); requested != nil && book.check(node.Name, requested) == nil {
+			addr = requested
+		}
+	}
+	if addr == nil {
+		addr, err = book.allocate(node.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := book.lease(node.Name, addr); err != nil {
+		return nil, err
+	}
+	assigned := *node
+	assigned.IP = addr.String()
+	assigned.Subnet = book.subnet.String()
+	if assigned.IP != node.IP || assigned.Subnet != node.Subnet {
+		assigned.Version++
+	}
+	return &assigned, nil
+}
+
+// Accept address assigned by address authority to self node. Scripts are re-generated and running network
+// is restarted. Returns true if self node changed
+func (pool *Pool) AcceptAssignment(name string, assigned *network.Node) (bool, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return false, err
+	}
+	self, config, err := ntw.SelfConfig()
+	if err != nil {
+		return false, err
+	}
+	if assigned.Name != self.Name || (assigned.IP == self.IP && assigned.Subnet == self.Subnet) {
+		return false, nil
+	}
+	_, subnet, err := net.ParseCIDR(assigned.Subnet)
+	if err != nil {
+		return false, fmt.Errorf("parse assigned subnet: %w", err)
+	}
+	if addr := net.ParseIP(assigned.IP); addr == nil || !subnet.Contains(addr) {
+		return false, fmt.Errorf("assigned address %s is not in subnet %s", assigned.IP, subnet)
+	}
+	self.IP = assigned.IP
+	self.Subnet = subnet.String()
+	self.Version++
+	if assigned.Version > self.Version {
+		self.Version = assigned.Version
+	}
+	data, err := self.Build()
+	if err != nil {
+		return false, err
+	}
+	err = pool.watcher.change(func() error {
+		if mask, _ := subnet.Mask.Size(); mask != config.Mask {
+			config.Mask = mask
+			if err := ntw.Update(config); err != nil {
+				return err
+			}
+		}
+		if err := ioutil.WriteFile(ntw.NodeFile(self.Name), data, 0755); err != nil {
+			return err
+		}
+		// tinc-up and tinc-down contain self address, configuration itself is kept
+		return ntw.Configure(subnet)
+	}, ntw)
+	if err != nil {
+		return false, err
+	}
+	if pool.IsRunning(name) {
+		return true, pool.Restart(name)
+	}
+	return true, nil
+}
+
+// addresses used in network: self node, known hosts and leases
+type addressBook struct {
+	ntw    *network.Network
+	self   *network.Node
+	subnet *net.IPNet
+	leases map[string]*Lease // node -> lease
+	used   map[string]string // ip -> node
+}
+
+func loadAddressBook(ntw *network.Network) (*addressBook, error) {
+	self, err := ntw.Self()
+	if err != nil {
+		return nil, err
+	}
+	_, subnet, err := net.ParseCIDR(self.Subnet)
+	if err != nil {
+		return nil, fmt.Errorf("parse subnet of self node: %w", err)
+	}
+	if subnet.IP.To4() == nil {
+		return nil, fmt.Errorf("only IPv4 subnets are supported")
+	}
+	nodes, err := ntw.NodesDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	list, err := readLeases(ntw)
+	if err != nil {
+		return nil, err
+	}
+	book := &addressBook{
+		ntw:    ntw,
+		self:   self,
+		subnet: subnet,
+		leases: make(map[string]*Lease),
+		used:   make(map[string]string),
+	}
+	for _, node := range nodes {
+		book.used[node.IP] = node.Name
+	}
+	for _, lease := range list {
+		book.leases[lease.Node] = lease
+		book.used[lease.IP] = lease.Node
+	}
+	book.used[self.IP] = self.Name
+	return book, nil
+}
+
+// check that address could be used by node
+func (book *addressBook) check(node string, addr net.IP) error {
+	if !book.subnet.Contains(addr) {
+		return fmt.Errorf("address %s is not in subnet %s", addr, book.subnet)
+	}
+	first, last := book.bounds()
+	if v := binary.BigEndian.Uint32(addr.To4()); v < first || v > last {
+		return fmt.Errorf("address %s is reserved in subnet %s", addr, book.subnet)
+	}
+	if owner, ok := book.used[addr.String()]; ok && owner != node {
+		return fmt.Errorf("address %s is already used by node %s", addr, owner)
+	}
+	return nil
+}
+
+// first free address in subnet
+func (book *addressBook) allocate(node string) (net.IP, error) {
+	first, last := book.bounds()
+	for v := first; v <= last && v >= first; v++ {
+		var addr = make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(addr, v)
+		if owner, ok := book.used[addr.String()]; !ok || owner == node {
+			return addr, nil
+		}
+	}
+	return nil, fmt.Errorf("no free addresses in subnet %s", book.subnet)
+}
+
+// range of host addresses in subnet (without network and broadcast addresses)
+func (book *addressBook) bounds() (first, last uint32) {
+	ones, bits := book.subnet.Mask.Size()
+	base := binary.BigEndian.Uint32(book.subnet.IP.To4())
+	size := uint32(1)<<uint(bits-ones) - 1
+	if size < 2 {
+		return base, base + size
+	}
+	return base + 1, base + size - 1
+}
+
+func (book *addressBook) lease(node string, addr net.IP) (*Lease, error) {
+	if old, ok := book.leases[node]; ok {
+		delete(book.used, old.IP)
+	}
+	lease := &Lease{Node: node, IP: addr.String(), Updated: time.Now()}
+	book.leases[node] = lease
+	book.used[lease.IP] = node
+	var list = make([]*Lease, 0, len(book.leases))
+	for _, item := range book.leases {
+		list = append(list, item)
+	}
+	return lease, writeLeases(book.ntw, list)
+}
+
+func readLeases(ntw *network.Network) ([]*Lease, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ntw.Root, leasesFile))
+	if os.IsNotExist(err) {
+		return []*Lease{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Lease
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse leases of %s: %w", ntw.Name(), err)
+	}
+	return list, nil
+}
+
+func writeLeases(ntw *network.Network, list []*Lease) error {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Node < list[j].Node
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ntw.Root, leasesFile), data, 0600)
+}
@@ -0,0 +1,125 @@
+package pool
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ownerKeyFile       = "owner.key"            // key of network owner for signing shared documents
+	trustedSignersFile = "trusted-signers.json" // fingerprints of keys trusted to sign shared documents
+)
+
+// Fingerprint of signing key (same format as in OpenSSH)
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// Key of network owner for signing shared documents. Key is generated on first use
+func (pool *Pool) OwnerKey(name string) (ed25519.PrivateKey, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", name)
+	}
+	pool.signersLock.Lock()
+	defer pool.signersLock.Unlock()
+	file := filepath.Join(ntw.Root, ownerKeyFile)
+	data, err := ioutil.ReadFile(file)
+	if err == nil {
+		return parseOwnerKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return key, ioutil.WriteFile(file, data, 0600)
+}
+
+// Fingerprints of keys trusted to sign documents of network (key of owner is not included)
+func (pool *Pool) TrustedSigners(name string) ([]string, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	pool.signersLock.Lock()
+	defer pool.signersLock.Unlock()
+	return readTrustedSigners(ntw)
+}
+
+// Trust key to sign documents of network. Returns true if key was not trusted before
+func (pool *Pool) TrustSigner(name, fingerprint string) (bool, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return false, err
+	}
+	pool.signersLock.Lock()
+	defer pool.signersLock.Unlock()
+	list, err := readTrustedSigners(ntw)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if item == fingerprint {
+			return false, nil
+		}
+	}
+	list = append(list, fingerprint)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(filepath.Join(ntw.Root, trustedSignersFile), data, 0600)
+}
+
+func parseOwnerKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("owner key is not in PEM format")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse owner key: %w", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("owner key is not ed25519 key")
+	}
+	return private, nil
+}
+
+func readTrustedSigners(ntw *network.Network) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ntw.Root, trustedSignersFile))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse trusted signers of %s: %w", ntw.Name(), err)
+	}
+	return list, nil
+}
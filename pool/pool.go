@@ -2,29 +2,60 @@ package pool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/tinc-boot/tincd"
 	"github.com/tinc-boot/tincd/network"
+	"log"
 	"net"
 	"os"
 	"path/filepath"
 	"sync"
 )
 
+var errReloadNotSupported = errors.New("reload is not supported")
+
 func New(ctx context.Context, configFile, rootDir, tincBin string) (*Pool, error) {
+	return NewWithRunner(ctx, configFile, rootDir, tincBin, TincdRunner)
+}
+
+// Same as New but tincd instances are started by custom runner
+func NewWithRunner(ctx context.Context, configFile, rootDir, tincBin string, runner Runner) (*Pool, error) {
 	pool := &Pool{
+		runner:  runner,
 		tincBin: tincBin,
 		rootDir: rootDir,
-		nets:    map[string]tincd.Tincd{},
+		nets:    map[string]*supervisor{},
 		ctx:     ctx,
 	}
 
 	err := pool.Config.LoadFrom(configFile)
-	if err != nil && !os.IsNotExist(err) {
+	if os.IsNotExist(err) {
+		pool.Config.Version = ConfigVersion
+	} else if err != nil {
 		return nil, err
 	}
 	pool.Config._filename = configFile
 
+	if _, err := pool.listTrash(); err != nil {
+		log.Println("failed to purge trash:", err)
+	}
+
+	if !pool.Config.Watch.Disabled {
+		if _, _, _, err := pool.watcher.scan(rootDir); err != nil {
+			return nil, err
+		}
+		go pool.watch(pool.Config.Watch)
+	}
+
+	for _, bin := range pool.Binaries() {
+		if bin.Error != "" {
+			log.Println("[WARN]", "detect version of", bin.Path, ":", bin.Error)
+			continue
+		}
+		log.Println("detected tincd", bin.Version, "at", bin.Path)
+	}
+
 	list, err := network.List(rootDir)
 	if err != nil {
 		return nil, err
@@ -50,23 +81,42 @@ func New(ctx context.Context, configFile, rootDir, tincBin string) (*Pool, error
 }
 
 type Pool struct {
+	runner  Runner
 	tincBin string
 	rootDir string
 	lock    sync.Mutex
 	ctx     context.Context
-	nets    map[string]tincd.Tincd
+	nets    map[string]*supervisor
 	Config  Config
 	events  network.Events
+	life    Lifecycle
+	watcher watcher
+	exits   map[string]exitInfo // last exit of stopped networks
+
+	versionsLock sync.Mutex
+	versions     map[string]string // binary -> version
+
+	leasesLock      sync.Mutex
+	invitationsLock sync.Mutex
+	signersLock     sync.Mutex
 }
 
 func (pool *Pool) Events() *network.Events {
 	return &pool.events
 }
 
+// Events related to supervision of tincd instances
+func (pool *Pool) Lifecycle() *Lifecycle {
+	return &pool.life
+}
+
 func (pool *Pool) Find(name string) tincd.Tincd {
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
-	return pool.nets[name]
+	if sv, ok := pool.nets[name]; ok {
+		return sv
+	}
+	return nil
 }
 
 func (pool *Pool) IsRunning(name string) bool {
@@ -84,38 +134,85 @@ func (pool *Pool) Network(name string) (*network.Network, error) {
 func (pool *Pool) RunNetwork(ntw *network.Network) (tincd.Tincd, error) {
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
-	runningInstance, ok := pool.nets[ntw.Name()]
-	if ok {
-		return runningInstance, nil
+	if runningInstance, ok := pool.nets[ntw.Name()]; ok {
+		select {
+		case <-runningInstance.Done():
+			// stopped but not yet removed from pool
+		default:
+			return runningInstance, nil
+		}
 	}
 	if pool.nets == nil {
-		pool.nets = make(map[string]tincd.Tincd)
+		pool.nets = make(map[string]*supervisor)
 	}
 
-	instance, err := tincd.Start(pool.ctx, ntw, false)
-	if err != nil {
+	bin := pool.binaryForUnsafe(ntw.Name())
+	instance := newSupervisor(pool.ctx, ntw, pool.Config.RestartPolicy(ntw.Name()), &pool.life, func(ctx context.Context, ntw *network.Network) (tincd.Tincd, error) {
+		return pool.runner.Start(ctx, ntw, bin)
+	})
+	instance.Events().SubscribeAll(pool.events.Emitter())
+	if err := instance.Start(); err != nil {
 		return nil, err
 	}
-	instance.Events().SubscribeAll(pool.events.Emitter())
 
 	pool.nets[ntw.Name()] = instance
 	go func() {
 		<-instance.Done()
 		pool.lock.Lock()
-		delete(pool.nets, ntw.Name())
+		if pool.nets[ntw.Name()] == instance {
+			delete(pool.nets, ntw.Name())
+		}
+		if pool.exits == nil {
+			pool.exits = make(map[string]exitInfo)
+		}
+		pool.exits[ntw.Name()] = exitInfo{err: instance.Error(), restarts: instance.Restarts()}
 		pool.lock.Unlock()
-		pool.events.Stopped.Emit(network.NetworkID{Name: ntw.Name()})
 	}()
 	return instance, nil
 }
 
+// Create network if not exists. Empty subnet will be allocated automatically. Subnet of new network should not
+// overlap with subnets of other networks and host routes.
 func (pool *Pool) Create(name string, subnet *net.IPNet) (*network.Network, error) {
+	location, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
-	return tincd.CreateNet(filepath.Join(pool.rootDir, name), subnet)
+	if !location.IsDefined() {
+		if subnet == nil {
+			subnet, err = pool.allocateSubnet(name)
+		} else {
+			err = pool.checkSubnet(name, subnet)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	var ntw *network.Network
+	err = pool.watcher.change(func() (err error) {
+		ntw, err = tincd.CreateNet(location.Root, subnet)
+		return err
+	}, location)
+	if err != nil {
+		return nil, err
+	}
+	return ntw, nil
 }
 
+// Put node definition to network (see network.Put). Change is made by daemon, so directory watcher is not notified
+func (pool *Pool) PutNode(ntw *network.Network, node *network.Node) error {
+	return pool.watcher.change(func() error {
+		return ntw.Put(node)
+	}, ntw)
+}
+
+// Stop network and move it to trash. Autostart and custom restart policy are cleared.
 func (pool *Pool) Remove(name string) (bool, error) {
+	if !network.IsValidName(name) {
+		return false, fmt.Errorf("invalid name for network")
+	}
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
 
@@ -125,9 +222,24 @@ func (pool *Pool) Remove(name string) (bool, error) {
 	if ok {
 		v.Stop()
 		<-v.Done()
-		return ok, v.Error()
 	}
-	return ok, nil
+
+	var existed bool
+	err := pool.watcher.change(func() (err error) {
+		existed, err = pool.moveToTrash(name)
+		return err
+	}, &network.Network{Root: filepath.Join(pool.rootDir, name)})
+	if err != nil {
+		return ok, err
+	}
+	if pool.Config.AutoStart.Has(name) || pool.Config.Restart[name].Mode != "" {
+		pool.Config.AutoStart.Del(name)
+		delete(pool.Config.Restart, name)
+		if err := pool.Config.Save(); err != nil {
+			return true, err
+		}
+	}
+	return ok || existed, nil
 }
 
 func (pool *Pool) Nets() ([]*network.Network, error) {
@@ -137,9 +249,16 @@ func (pool *Pool) Nets() ([]*network.Network, error) {
 func (pool *Pool) Stop() {
 	var wg sync.WaitGroup
 
+	pool.lock.Lock()
+	var list = make([]*supervisor, 0, len(pool.nets))
 	for _, impl := range pool.nets {
+		list = append(list, impl)
+	}
+	pool.lock.Unlock()
+
+	for _, impl := range list {
 		wg.Add(1)
-		go func(impl tincd.Tincd) {
+		go func(impl *supervisor) {
 			defer wg.Done()
 			impl.Stop()
 			<-impl.Done()
@@ -148,3 +267,107 @@ func (pool *Pool) Stop() {
 
 	wg.Wait()
 }
+
+// Check that network should be started automatically
+func (pool *Pool) IsAutoStart(name string) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.Config.AutoStart.Has(name)
+}
+
+// Enable or disable network autostart and save configuration
+func (pool *Pool) SetAutoStart(name string, enabled bool) error {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return err
+	}
+	if enabled && !ntw.IsDefined() {
+		return fmt.Errorf("network %s is not defined", name)
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if enabled {
+		pool.Config.AutoStart.Set(name)
+	} else {
+		pool.Config.AutoStart.Del(name)
+	}
+	return pool.Config.Save()
+}
+
+// Names of networks that should be started automatically
+func (pool *Pool) AutoStarts() []string {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.Config.AutoStart.List()
+}
+
+// Effective restart policy for the network
+func (pool *Pool) RestartPolicy(name string) RestartPolicy {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.Config.RestartPolicy(name)
+}
+
+// Set custom restart policy for the network and save configuration. Empty mode resets policy to default.
+// Applied immediately for running instance.
+func (pool *Pool) SetRestartPolicy(name string, policy RestartPolicy) error {
+	if !network.IsValidName(name) {
+		return fmt.Errorf("invalid name for network")
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if policy.Mode == "" {
+		delete(pool.Config.Restart, name)
+	} else {
+		if pool.Config.Restart == nil {
+			pool.Config.Restart = make(map[string]RestartPolicy)
+		}
+		pool.Config.Restart[name] = policy
+	}
+	if sv, ok := pool.nets[name]; ok {
+		sv.SetPolicy(pool.Config.RestartPolicy(name))
+	}
+	return pool.Config.Save()
+}
+
+// Reload configuration of running network (restart if reload is not supported by platform)
+func (pool *Pool) Reload(name string) error {
+	_, err := pool.reload(name)
+	return err
+}
+
+// reload configuration of running network and report was it reloaded (true) or restarted (false)
+func (pool *Pool) reload(name string) (bool, error) {
+	pool.lock.Lock()
+	instance, ok := pool.nets[name]
+	pool.lock.Unlock()
+	if !ok || !instance.IsRunning() {
+		return false, fmt.Errorf("network %s is not running", name)
+	}
+	ntw := instance.Definition()
+	if err := ntw.Prepare(pool.ctx, pool.binaryFor(name)); err != nil {
+		return false, err
+	}
+	err := instance.Reload()
+	if err == errReloadNotSupported {
+		return false, pool.Restart(name)
+	}
+	return err == nil, err
+}
+
+// Stop network (if running) and start it again
+func (pool *Pool) Restart(name string) error {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return err
+	}
+	if instance := pool.Find(name); instance != nil {
+		instance.Stop()
+		<-instance.Done()
+	}
+	_, err = pool.RunNetwork(ntw)
+	return err
+}
@@ -0,0 +1,1253 @@
+package pool_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tinc-web-boot/pool"
+	"tinc-web-boot/pool/simulator"
+)
+
+type testPool struct {
+	*pool.Pool
+	runner *simulator.Runner
+	dir    string
+	config string
+	cancel func()
+}
+
+func newTestPool(t *testing.T) *testPool {
+	return newTestPoolWithConfig(t, nil)
+}
+
+// test pool with initial config (nil - default)
+func newTestPoolWithConfig(t *testing.T, config *pool.Config) *testPool {
+	dir, err := ioutil.TempDir("", "pool-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "networks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		if err := config.SaveAs(filepath.Join(dir, "tinc-web.json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tp := &testPool{
+		runner: &simulator.Runner{},
+		dir:    dir,
+		config: filepath.Join(dir, "tinc-web.json"),
+		cancel: cancel,
+	}
+	tp.Pool, err = pool.NewWithRunner(ctx, tp.config, filepath.Join(dir, "networks"), "tincd", tp.runner)
+	if err != nil {
+		cancel()
+		_ = os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return tp
+}
+
+func (tp *testPool) Close() {
+	tp.Stop()
+	tp.cancel()
+	_ = os.RemoveAll(tp.dir)
+}
+
+func (tp *testPool) create(t *testing.T, name string) *network.Network {
+	ntw, err := tp.Create(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ntw
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+var fastRestarts = pool.RestartPolicy{
+	Mode:        pool.RestartOnFailure,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  20 * time.Millisecond,
+	MaxRestarts: 2,
+	Window:      time.Minute,
+}
+
+func TestPool_RunAndStatus(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+
+	var (
+		lock   sync.Mutex
+		joined []string
+		left   []string
+	)
+	tp.Events().PeerJoined.Subscribe(func(peer network.PeerID) {
+		lock.Lock()
+		defer lock.Unlock()
+		joined = append(joined, peer.Node)
+	})
+	tp.Events().PeerLeft.Subscribe(func(peer network.PeerID) {
+		lock.Lock()
+		defer lock.Unlock()
+		left = append(left, peer.Node)
+	})
+
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	if !tp.IsRunning("alpha") {
+		t.Fatal("network should be running")
+	}
+	proc := tp.runner.Process("alpha")
+	proc.Join("beta")
+	proc.Join("gamma")
+	proc.Leave("gamma")
+
+	status, err := tp.Status("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Running || status.PID != proc.PID() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if status.OnlinePeers != 1 || status.KnownPeers != 0 {
+		t.Errorf("unexpected peers in status: %+v", status)
+	}
+	if status.Started == nil {
+		t.Error("start time should be defined")
+	}
+
+	lock.Lock()
+	if len(joined) != 2 || len(left) != 1 || left[0] != "gamma" {
+		t.Errorf("unexpected events: joined %v, left %v", joined, left)
+	}
+	lock.Unlock()
+
+	instance := tp.Find("alpha")
+	instance.Stop()
+	<-instance.Done()
+	waitFor(t, "network removed from pool", func() bool { return tp.Find("alpha") == nil })
+
+	status, err = tp.Status("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Running || status.LastError != "" {
+		t.Errorf("unexpected status after stop: %+v", status)
+	}
+}
+
+func TestPool_RestartAfterCrash(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	if err := tp.SetRestartPolicy("alpha", fastRestarts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+
+	var restarting = make(chan pool.Restarting, 1)
+	tp.Lifecycle().Restarting.Subscribe(func(event pool.Restarting) {
+		restarting <- event
+	})
+
+	tp.runner.Process("alpha").Crash(errors.New("segfault"))
+	select {
+	case event := <-restarting:
+		if event.Network != "alpha" || event.Reason != "segfault" || event.Attempt != 1 {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("restart not scheduled")
+	}
+	waitFor(t, "restart", func() bool { return tp.runner.Starts("alpha") == 2 && tp.IsRunning("alpha") })
+
+	status, err := tp.Status("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Restarts != 1 || status.LastError != "segfault" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestPool_GiveUp(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	if err := tp.SetRestartPolicy("alpha", fastRestarts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+
+	var gaveUp = make(chan pool.GaveUp, 1)
+	tp.Lifecycle().GaveUp.Subscribe(func(event pool.GaveUp) {
+		gaveUp <- event
+	})
+
+	tp.runner.FailStart("alpha", errors.New("no device"))
+	tp.runner.Process("alpha").Crash(errors.New("segfault"))
+
+	select {
+	case event := <-gaveUp:
+		if event.Restarts != fastRestarts.MaxRestarts || event.Reason != "no device" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor should give up")
+	}
+	waitFor(t, "network stopped", func() bool { return tp.Find("alpha") == nil })
+}
+
+func TestPool_StopIsNotFailure(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	if err := tp.SetRestartPolicy("alpha", fastRestarts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.Restart("alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if tp.runner.Starts("alpha") != 2 {
+		t.Errorf("expected 2 starts, got %d", tp.runner.Starts("alpha"))
+	}
+	status, err := tp.Status("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Restarts != 0 {
+		t.Errorf("manual restart should not be counted: %+v", status)
+	}
+}
+
+func TestPool_Reload(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	if err := tp.Reload("alpha"); err == nil {
+		t.Error("reload of stopped network should fail")
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.Reload("alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if reloads := tp.runner.Process("alpha").Reloads(); reloads != 1 {
+		t.Errorf("expected 1 reload, got %d", reloads)
+	}
+	if tp.runner.Starts("alpha") != 1 {
+		t.Error("reload should not restart network")
+	}
+}
+
+func TestPool_Watcher(t *testing.T) {
+	tp := newTestPoolWithConfig(t, &pool.Config{Watch: pool.WatchConfig{Interval: 20 * time.Millisecond, Reload: true}})
+	defer tp.Close()
+	var (
+		added   = make(chan pool.NetworkChanged, 16)
+		removed = make(chan pool.NetworkChanged, 16)
+		hosts   = make(chan pool.HostsChanged, 16)
+	)
+	tp.Lifecycle().NetworkAdded.Subscribe(func(event pool.NetworkChanged) { added <- event })
+	tp.Lifecycle().NetworkRemoved.Subscribe(func(event pool.NetworkChanged) { removed <- event })
+	tp.Lifecycle().HostsChanged.Subscribe(func(event pool.HostsChanged) { hosts <- event })
+	const publicKey = "-----BEGIN RSA PUBLIC KEY-----\nMAoCAwEAAQIDAQAB\n-----END RSA PUBLIC KEY-----"
+	nextHosts := func(what string) pool.HostsChanged {
+		for {
+			select {
+			case event := <-hosts:
+				if event.Network == "alpha" {
+					return event
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for", what)
+			}
+		}
+	}
+
+	alpha := tp.create(t, "alpha")
+	if _, err := tp.RunNetwork(alpha); err != nil {
+		t.Fatal(err)
+	}
+	self, err := alpha.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// network created outside of daemon
+	_, subnet, _ := net.ParseCIDR("10.201.0.0/16")
+	external, err := tincd.CreateNet(filepath.Join(tp.dir, "beta"), subnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(external.Root, filepath.Join(tp.dir, "networks", "beta")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-added:
+		if event.Network != "beta" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("added network should be detected")
+	}
+
+	// host file added outside of daemon
+	putHost := func(name string) {
+		data, err := (&network.Node{Name: name, Subnet: self.Subnet, PublicKey: publicKey, Version: 1}).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmp := filepath.Join(tp.dir, name)
+		if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, alpha.NodeFile(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	putHost("gamma")
+	event := nextHosts("hosts change")
+	if len(event.Added) != 1 || event.Added[0] != "gamma" || !event.Reloaded {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if reloads := tp.runner.Process("alpha").Reloads(); reloads != 1 {
+		t.Errorf("running network should be reloaded once, got %d", reloads)
+	}
+
+	// changes made by daemon are not reported
+	if err := tp.PutNode(alpha, &network.Node{Name: "delta", Subnet: self.Subnet, PublicKey: publicKey, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	putHost("epsilon")
+	event = nextHosts("next hosts change")
+	if len(event.Added) != 1 || event.Added[0] != "epsilon" || len(event.Updated) != 0 {
+		t.Errorf("only external change should be reported: %+v", event)
+	}
+
+	if err := os.RemoveAll(filepath.Join(tp.dir, "networks", "beta")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-removed:
+		if event.Network != "beta" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("removed network should be detected")
+	}
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("networks managed by daemon should not be reported: %d added, %d removed", len(added), len(removed))
+	}
+}
+
+func TestPool_AutoStart(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	tp.create(t, "alpha")
+	tp.create(t, "beta")
+	if err := tp.SetAutoStart("alpha", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.SetAutoStart("missing", true); err == nil {
+		t.Error("autostart of undefined network should fail")
+	}
+	tp.Stop()
+
+	var runner simulator.Runner
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	restored, err := pool.NewWithRunner(ctx, tp.config, filepath.Join(tp.dir, "networks"), "tincd", &runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Stop()
+	if !restored.IsRunning("alpha") || restored.IsRunning("beta") {
+		t.Error("only alpha should be started automatically")
+	}
+	if list := restored.AutoStarts(); len(list) != 1 || list[0] != "alpha" {
+		t.Errorf("unexpected autostart list: %v", list)
+	}
+}
+
+func TestPool_CustomBinary(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	alpha := tp.create(t, "alpha")
+	beta := tp.create(t, "beta")
+	tp.Config.TincBin = map[string]string{"beta": "/opt/tinc-1.1/sbin/tincd"}
+
+	if _, err := tp.RunNetwork(alpha); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(beta); err != nil {
+		t.Fatal(err)
+	}
+	if bin := tp.runner.Process("alpha").Binary(); bin != "tincd" {
+		t.Errorf("alpha should use default binary, got %s", bin)
+	}
+	if bin := tp.runner.Process("beta").Binary(); bin != "/opt/tinc-1.1/sbin/tincd" {
+		t.Errorf("beta should use custom binary, got %s", bin)
+	}
+	var custom *pool.TincBinary
+	for _, bin := range tp.Binaries() {
+		if !bin.Default {
+			custom = bin
+		}
+	}
+	if custom == nil || len(custom.Networks) != 1 || custom.Networks[0] != "beta" || custom.Error == "" {
+		t.Errorf("unexpected custom binary info: %+v", custom)
+	}
+}
+
+func TestPool_TemplateCompression(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tincd binary is a shell script")
+	}
+	tp := newTestPool(t)
+	defer tp.Close()
+	legacyBin := filepath.Join(tp.dir, "tincd-1.0")
+	if err := ioutil.WriteFile(legacyBin, []byte("#!/bin/sh\necho 'tinc version 1.0.36'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tp.Config.TincBin = map[string]string{"legacy": legacyBin}
+	if err := tp.SaveTemplate(pool.Template{Name: "compressed", Compression: 9}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tp.CreateFromTemplate("legacy", nil, "compressed"); err == nil {
+		t.Error("compression should be rejected for tincd 1.0")
+	}
+	if ntw, _ := tp.Network("legacy"); ntw.IsDefined() {
+		t.Error("network should not be created")
+	}
+
+	ntw, err := tp.CreateFromTemplate("alpha", nil, "compressed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(ntw.Root, "conf.d", "template.conf")
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Compression = 9\n" {
+		t.Errorf("unexpected options: %q", data)
+	}
+	if info, err := os.Stat(file); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0644 {
+		t.Errorf("options file should not be executable: %v", info.Mode())
+	}
+}
+
+func TestPool_RemoveAndRestore(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	if err := tp.SetAutoStart("alpha", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := tp.Remove("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !removed || tp.IsRunning("alpha") || ntw.IsDefined() {
+		t.Fatal("network should be stopped and removed")
+	}
+	if tp.IsAutoStart("alpha") {
+		t.Error("autostart should be cleared")
+	}
+
+	items, err := tp.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Network != "alpha" {
+		t.Fatalf("unexpected trash: %+v", items)
+	}
+
+	tp.create(t, "alpha")
+	if _, err := tp.Restore(items[0].ID); err == nil {
+		t.Error("restore over existing network should fail")
+	}
+	if _, err := tp.Remove("alpha"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := tp.Restore(items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.IsDefined() {
+		t.Error("restored network should be defined")
+	}
+	items, err = tp.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Errorf("only second removal should stay in trash: %+v", items)
+	}
+}
+
+func TestPool_RestoreExpired(t *testing.T) {
+	tp := newTestPoolWithConfig(t, &pool.Config{TrashRetention: 100 * time.Millisecond})
+	defer tp.Close()
+	tp.create(t, "alpha")
+	if _, err := tp.Remove("alpha"); err != nil {
+		t.Fatal(err)
+	}
+	items, err := tp.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("unexpected trash: %+v", items)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, err := tp.Restore(items[0].ID); err == nil {
+		t.Error("expired item should not be restored")
+	}
+	if _, err := os.Stat(filepath.Join(tp.dir, "networks", ".trash", items[0].ID)); !os.IsNotExist(err) {
+		t.Errorf("expired item should be purged: %v", err)
+	}
+	if ntw, err := tp.Network("alpha"); err == nil && ntw.IsDefined() {
+		t.Error("network should not be restored")
+	}
+}
+
+func TestPool_Upgrade(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+
+	result, err := tp.Upgrade("alpha", network.Upgrade{Port: 30123})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.RestartRequired || result.Reloaded || result.Node.Port != 30123 {
+		t.Errorf("unexpected result for stopped network: %+v", result)
+	}
+
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	result, err = tp.Upgrade("alpha", network.Upgrade{Address: []network.Address{{Host: "example.com"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RestartRequired || !result.Reloaded {
+		t.Errorf("address change should be reloaded: %+v", result)
+	}
+	if tp.runner.Process("alpha").Reloads() != 1 || tp.runner.Starts("alpha") != 1 {
+		t.Error("network should be reloaded without restart")
+	}
+
+	result, err = tp.Upgrade("alpha", network.Upgrade{Address: []network.Address{{Host: "example.com"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RestartRequired || result.Reloaded {
+		t.Errorf("nothing should be applied without changes: %+v", result)
+	}
+
+	result, err = tp.Upgrade("alpha", network.Upgrade{Port: 30124})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.RestartRequired || result.Reloaded {
+		t.Errorf("port change should require restart: %+v", result)
+	}
+	if tp.runner.Starts("alpha") != 2 || !tp.IsRunning("alpha") {
+		t.Error("network should be restarted")
+	}
+}
+
+func TestPool_Rename(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	ntw := tp.create(t, "alpha")
+	_, config, err := ntw.SelfConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.SetAutoStart("alpha", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	tp.create(t, "gamma")
+	if _, err := tp.Rename("alpha", "gamma"); err == nil {
+		t.Error("rename to existent network should fail")
+	}
+
+	renamed, err := tp.Rename("alpha", "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name() != "beta" || ntw.IsDefined() || !renamed.IsDefined() {
+		t.Fatal("network should be moved")
+	}
+	if tp.IsRunning("alpha") || !tp.IsRunning("beta") {
+		t.Error("renamed network should be started again")
+	}
+	if tp.IsAutoStart("alpha") || !tp.IsAutoStart("beta") {
+		t.Error("autostart should be moved")
+	}
+	renamedConfig, err := renamed.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamedConfig.Interface != config.Interface {
+		t.Error("interface name should be kept")
+	}
+}
+
+func TestPool_Clone(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	_, srcSubnet, _ := net.ParseCIDR("10.199.0.0/16")
+	ntw, err := tp.Create("alpha", srcSubnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.Upgrade("alpha", network.Upgrade{Address: []network.Address{{Host: "example.com"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(ntw.Root, "host-up"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	srcSelf, srcConfig, err := ntw.SelfConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, subnet, _ := net.ParseCIDR("10.200.0.0/16")
+	cloned, err := tp.Clone("alpha", "beta", subnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, config, err := cloned.SelfConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Name == srcSelf.Name || self.PublicKey == srcSelf.PublicKey || config.Interface == srcConfig.Interface {
+		t.Error("self node, keys and interface should be generated")
+	}
+	if self.Subnet != "10.200.0.0/16" {
+		t.Errorf("unexpected subnet %s", self.Subnet)
+	}
+	if len(self.Address) != 1 || self.Address[0].Host != "example.com" {
+		t.Errorf("public addresses should be copied: %+v", self.Address)
+	}
+	if config.Mode != srcConfig.Mode || config.Broadcast != srcConfig.Broadcast {
+		t.Errorf("settings should be copied: %+v", config)
+	}
+	if _, err := os.Stat(filepath.Join(cloned.Root, "host-up")); err != nil {
+		t.Error("scripts should be copied:", err)
+	}
+	if _, err := tp.Clone("alpha", "beta", nil); err == nil {
+		t.Error("clone to existent network should fail")
+	}
+}
+
+func TestPool_SubnetAllocation(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	tp.Config.IPAM = pool.IPAMConfig{Ranges: []string{"10.201.0.0/23"}, Prefix: 24}
+
+	_, subnet, _ := net.ParseCIDR("10.201.0.0/24")
+	if _, err := tp.Create("alpha", subnet); err != nil {
+		t.Fatal(err)
+	}
+	_, overlapped, _ := net.ParseCIDR("10.201.0.128/25")
+	if _, err := tp.Create("beta", overlapped); err == nil || !strings.Contains(err.Error(), "network alpha") {
+		t.Errorf("overlapped subnet should be rejected with explanation, got %v", err)
+	}
+
+	allocated, err := tp.Create("beta", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := allocated.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Subnet != "10.201.1.0/24" {
+		t.Errorf("expected the only free subnet, got %s", self.Subnet)
+	}
+	if _, err := tp.Create("gamma", nil); err == nil {
+		t.Error("allocation should fail without free subnets")
+	}
+	if _, err := tp.Create("alpha", nil); err != nil {
+		t.Error("existent network should be returned without checks:", err)
+	}
+}
+
+func TestPool_Leases(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+
+	_, subnet, _ := net.ParseCIDR("10.202.0.0/29")
+	ntw, err := tp.Create("alpha", subnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// self address is generated randomly by library
+	self.IP = "10.202.0.1"
+	data, err := self.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(ntw.NodeFile(self.Name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	joiner := &network.Node{Name: "beta", Subnet: "10.99.0.0/16", IP: self.IP, PublicKey: "key", Version: 1}
+	assigned, err := tp.Assign("alpha", joiner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned.IP != "10.202.0.2" || assigned.Subnet != subnet.String() || assigned.Version != 2 {
+		t.Errorf("conflicting address should be re-assigned to first free: %+v", assigned)
+	}
+	again, err := tp.Assign("alpha", joiner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.IP != assigned.IP {
+		t.Errorf("leased address should be kept: %s != %s", again.IP, assigned.IP)
+	}
+	if _, err := tp.Assign("alpha", &network.Node{Name: self.Name, IP: self.IP}); err == nil {
+		t.Error("node with name of self node should be rejected")
+	}
+
+	if _, err := tp.SetLease("alpha", "gamma", assigned.IP); err == nil || !strings.Contains(err.Error(), "beta") {
+		t.Errorf("used address should be rejected with owner, got %v", err)
+	}
+	if _, err := tp.SetLease("alpha", "gamma", "10.202.0.0"); err == nil {
+		t.Error("network address should be rejected")
+	}
+	if _, err := tp.SetLease("alpha", "gamma", "10.202.1.1"); err == nil {
+		t.Error("address outside subnet should be rejected")
+	}
+	// 6 host addresses in /29: self, beta and 4 more nodes
+	var expected = []pool.Lease{{Node: "beta", IP: "10.202.0.2"}}
+	for i := 3; i <= 6; i++ {
+		name := "node" + string('a'+rune(i))
+		node, err := tp.Assign("alpha", &network.Node{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ip := "10.202.0." + string('0'+rune(i))
+		if node.IP != ip {
+			t.Errorf("%s should get %s, got %s", name, ip, node.IP)
+		}
+		expected = append(expected, pool.Lease{Node: name, IP: ip})
+	}
+	if _, err := tp.Assign("alpha", &network.Node{Name: "extra"}); err == nil {
+		t.Error("assign should fail without free addresses")
+	}
+
+	leases, err := tp.Leases("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != len(expected) {
+		t.Fatalf("unexpected leases: %+v", leases)
+	}
+	for i, lease := range leases {
+		if lease.Node != expected[i].Node || lease.IP != expected[i].IP {
+			t.Errorf("lease %d should be %s=%s, got %+v", i, expected[i].Node, expected[i].IP, lease)
+		}
+	}
+	removed, err := tp.RemoveLease("alpha", "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !removed {
+		t.Error("lease should be removed")
+	}
+	if _, err := tp.SetLease("alpha", "gamma", assigned.IP); err != nil {
+		t.Errorf("released address should be available: %v", err)
+	}
+}
+
+func TestPool_Invitations(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	tp.create(t, "alpha")
+
+	joins := 0
+	join := func() error {
+		joins++
+		return nil
+	}
+	expires := time.Now().Add(time.Hour)
+	if _, err := tp.CreateInvitation("alpha", pool.Invitation{ID: "once", MaxUses: 1, Expires: expires}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.CreateInvitation("alpha", pool.Invitation{ID: "once", Expires: expires}); err == nil {
+		t.Error("duplicated invitation should be rejected")
+	}
+	if _, err := tp.CreateInvitation("missing", pool.Invitation{ID: "any", Expires: expires}); err == nil {
+		t.Error("invitation to undefined network should be rejected")
+	}
+
+	if _, err := tp.RedeemInvitation("alpha", "once", pool.Redemption{Node: "beta"}, func() error {
+		return errors.New("failed")
+	}); err == nil {
+		t.Error("failed join should be reported")
+	}
+	inv, err := tp.RedeemInvitation("alpha", "once", pool.Redemption{Node: "beta", Address: "10.0.0.2"}, join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Redemptions) != 1 || inv.Redemptions[0].Node != "beta" || inv.IsActive() {
+		t.Errorf("only successful join should be recorded: %+v", inv)
+	}
+	if _, err := tp.RedeemInvitation("alpha", "once", pool.Redemption{Node: "gamma"}, join); !errors.Is(err, pool.ErrInvitationNotAccepted) {
+		t.Errorf("used invitation should be rejected, got %v", err)
+	}
+
+	if _, err := tp.CreateInvitation("alpha", pool.Invitation{ID: "named", NodeName: "gamma", Expires: expires}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RedeemInvitation("alpha", "named", pool.Redemption{Node: "beta"}, join); err == nil {
+		t.Error("invitation should be accepted only from named node")
+	}
+	if _, err := tp.RedeemInvitation("alpha", "named", pool.Redemption{Node: "gamma"}, join); err != nil {
+		t.Error(err)
+	}
+	revoked, err := tp.RevokeInvitation("alpha", "named")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("invitation should be revoked")
+	}
+	if _, err := tp.RedeemInvitation("alpha", "named", pool.Redemption{Node: "gamma"}, join); err == nil {
+		t.Error("revoked invitation should be rejected")
+	}
+
+	if _, err := tp.CreateInvitation("alpha", pool.Invitation{ID: "old", Expires: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RedeemInvitation("alpha", "old", pool.Redemption{Node: "delta"}, join); err == nil {
+		t.Error("expired invitation should be rejected")
+	}
+	if joins != 2 {
+		t.Errorf("join should be called only for valid invitations, called %d times", joins)
+	}
+
+	list, err := tp.Invitations("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].ID != "once" || !list[1].Revoked || len(list[1].Redemptions) != 1 {
+		t.Errorf("unexpected invitations: %+v", list)
+	}
+}
+
+func TestPool_JoinRequests(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	tp.create(t, "alpha")
+
+	if _, err := tp.CreateInvitation("alpha", pool.Invitation{ID: "inv", Approval: true, Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	beta := &network.Node{Name: "beta", PublicKey: "key"}
+	req, created, err := tp.RequestJoin("alpha", "inv", beta, "10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || req.Status != pool.JoinPending || req.Node.Name != "beta" {
+		t.Errorf("pending request should be created: %+v", req)
+	}
+	again, created, err := tp.RequestJoin("alpha", "inv", beta, "10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || again.ID != req.ID {
+		t.Errorf("repeated request should return existent one: %+v", again)
+	}
+	if _, _, err := tp.RequestJoin("alpha", "inv", &network.Node{Name: "beta", PublicKey: "other"}, ""); err == nil {
+		t.Error("request of node with another key should be rejected")
+	}
+	if _, _, err := tp.RequestJoin("alpha", "missing", beta, ""); err == nil {
+		t.Error("request without invitation should be rejected")
+	}
+
+	approved, err := tp.ApproveJoin("alpha", req.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != pool.JoinApproved {
+		t.Errorf("request should be approved: %+v", approved)
+	}
+	if _, err := tp.RejectJoin("alpha", req.ID); err == nil {
+		t.Error("decided request should not be changed")
+	}
+
+	gamma, _, err := tp.RequestJoin("alpha", "inv", &network.Node{Name: "gamma"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RejectJoin("alpha", gamma.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.CompleteJoin("alpha", req.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, err := tp.JoinRequests("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != gamma.ID || list[0].Status != pool.JoinRejected {
+		t.Errorf("only rejected request should be kept: %+v", list)
+	}
+}
+
+func TestPool_OwnerKey(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	tp.create(t, "alpha")
+
+	if _, err := tp.OwnerKey("missing"); err == nil {
+		t.Error("key of undefined network should not be generated")
+	}
+	key, err := tp.OwnerKey("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := tp.OwnerKey("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("owner key should be kept")
+	}
+
+	added, err := tp.TrustSigner("alpha", "SHA256:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added {
+		t.Error("signer should be added")
+	}
+	if added, _ := tp.TrustSigner("alpha", "SHA256:abc"); added {
+		t.Error("signer should be added once")
+	}
+	list, err := tp.TrustedSigners("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != "SHA256:abc" {
+		t.Errorf("unexpected trusted signers: %v", list)
+	}
+}
+
+func TestPool_AcceptAssignment(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+
+	_, subnet, _ := net.ParseCIDR("10.203.0.0/24")
+	ntw, err := tp.Create("alpha", subnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(ntw); err != nil {
+		t.Fatal(err)
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned := *self
+	assigned.IP = "10.203.0.250"
+	if self.IP == assigned.IP {
+		assigned.IP = "10.203.0.251"
+	}
+	assigned.Version++
+
+	changed, err := tp.AcceptAssignment("alpha", &assigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("self node should be changed")
+	}
+	updated, err := ntw.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.IP != assigned.IP || updated.Version != assigned.Version || updated.PublicKey != self.PublicKey {
+		t.Errorf("unexpected self node: %+v", updated)
+	}
+	if tp.runner.Starts("alpha") != 2 {
+		t.Errorf("running network should be restarted, starts: %d", tp.runner.Starts("alpha"))
+	}
+	changed, err = tp.AcceptAssignment("alpha", &assigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("same assignment should not change self node")
+	}
+}
+
+func TestPool_ConfigMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "tinc-web.json")
+	legacy := []byte(`{"auto_start": ["alpha"]}`)
+	if err := ioutil.WriteFile(configFile, legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg pool.Config
+	if err := cfg.LoadFrom(configFile); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != pool.ConfigVersion || !cfg.AutoStart.Has("alpha") {
+		t.Errorf("unexpected migrated config: %+v", cfg)
+	}
+	backup, err := ioutil.ReadFile(configFile + ".v0.bak")
+	if err != nil {
+		t.Fatal("original config should be kept:", err)
+	}
+	if string(backup) != string(legacy) {
+		t.Errorf("unexpected backup: %s", backup)
+	}
+	var saved pool.Config
+	if err := saved.LoadFrom(configFile); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != pool.ConfigVersion || !saved.AutoStart.Has("alpha") {
+		t.Errorf("migrated config should be saved: %+v", saved)
+	}
+
+	if err := ioutil.WriteFile(configFile, []byte(`{"version": 1000}`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.LoadFrom(configFile); err == nil {
+		t.Error("config of newer version should be rejected")
+	}
+}
+
+func TestPool_BackupRestore(t *testing.T) {
+	tp := newTestPool(t)
+	defer tp.Close()
+	alpha := tp.create(t, "alpha")
+	tp.create(t, "beta")
+	if err := tp.SetAutoStart("alpha", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(alpha); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(alpha.Root, "host-up"), []byte("#!/bin/sh\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	alphaSelf, err := alpha.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := tp.Backup(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Networks) != 2 || !manifest.Networks[0].Autostart || manifest.Networks[0].Node != alphaSelf.Name {
+		t.Fatalf("unexpected manifest: %+v", manifest.Networks)
+	}
+	for _, file := range manifest.Networks[0].Files {
+		if file == "pid.run" {
+			t.Error("pid file should not be saved")
+		}
+	}
+
+	restored := newTestPool(t)
+	defer restored.Close()
+	res, err := restored.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{Networks: []string{"alpha"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Restored) != 1 || res.Restored[0] != "alpha" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	ntw, err := restored.Network("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Name != alphaSelf.Name || self.PublicKey != alphaSelf.PublicKey {
+		t.Errorf("self node should be restored: %+v", self)
+	}
+	info, err := os.Stat(filepath.Join(ntw.Root, "host-up"))
+	if err != nil {
+		t.Fatal("scripts should be restored:", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("permissions should be kept: %v", info.Mode())
+	}
+	if !restored.IsAutoStart("alpha") {
+		t.Error("autostart should be restored")
+	}
+	if beta, _ := restored.Network("beta"); beta.IsDefined() {
+		t.Error("only selected networks should be restored")
+	}
+
+	res, err = restored.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{SkipExisting: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Restored) != 1 || res.Restored[0] != "beta" || len(res.Skipped) != 1 || res.Skipped[0] != "alpha" {
+		t.Errorf("existent network should be skipped: %+v", res)
+	}
+
+	// replace running network in place
+	res, err = tp.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{Networks: []string{"alpha"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tp.IsRunning("alpha") || tp.runner.Starts("alpha") != 2 {
+		t.Error("running network should be started again")
+	}
+	trash, err := tp.Trash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].Network != "alpha" {
+		t.Errorf("replaced network should be moved to trash: %+v", trash)
+	}
+
+	if _, err := tp.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{Networks: []string{"gamma"}}); err == nil {
+		t.Error("restore of unknown network should fail")
+	}
+	if _, err := tp.RestoreBackup(strings.NewReader("garbage"), pool.RestoreOptions{}); err == nil {
+		t.Error("restore of invalid archive should fail")
+	}
+}
+
+func TestPool_RestoreRunningWithRoutes(t *testing.T) {
+	// running network has host routes via own interface
+	var iface string
+	var route *net.IPNet
+	list, _ := net.Interfaces()
+	for _, item := range list {
+		addrs, _ := item.Addrs()
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLoopback() {
+				iface, route = item.Name, ipNet
+			}
+		}
+	}
+	if route == nil {
+		t.Skip("no IPv4 interfaces")
+	}
+	tp := newTestPool(t)
+	defer tp.Close()
+	alpha := tp.create(t, "alpha")
+	config, err := alpha.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Interface = iface
+	if err := alpha.Update(config); err != nil {
+		t.Fatal(err)
+	}
+	self, err := alpha.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	self.Subnet = route.String()
+	data, err := self.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(alpha.NodeFile(self.Name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.RunNetwork(alpha); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if _, err := tp.Backup(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := tp.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Restored) != 1 || res.Restored[0] != "alpha" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if !tp.IsRunning("alpha") {
+		t.Error("restored network should be started again")
+	}
+
+	// routes of foreign interface still conflict
+	other := newTestPool(t)
+	defer other.Close()
+	beta := other.create(t, "alpha")
+	if config, err := beta.Read(); err != nil {
+		t.Fatal(err)
+	} else if config.Interface == iface {
+		t.Skip("interface name collision")
+	}
+	if _, err := other.RestoreBackup(bytes.NewReader(archive.Bytes()), pool.RestoreOptions{}); err == nil {
+		t.Error("subnet of host route should not be restored over network with other interface")
+	}
+}
@@ -0,0 +1,17 @@
+// +build !windows
+
+package pool
+
+import (
+	"github.com/tinc-boot/tincd/network"
+	"syscall"
+)
+
+// signal tincd to reload configuration (SIGHUP)
+func reloadTincd(ntw *network.Network) error {
+	pid, err := readPid(ntw)
+	if err != nil {
+		return err
+	}
+	return syscall.Kill(pid, syscall.SIGHUP)
+}
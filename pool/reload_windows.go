@@ -0,0 +1,10 @@
+package pool
+
+import (
+	"github.com/tinc-boot/tincd/network"
+)
+
+// tincd on windows can't be reloaded by signal - restart is required
+func reloadTincd(ntw *network.Network) error {
+	return errReloadNotSupported
+}
@@ -0,0 +1,179 @@
+package pool
+
+import (
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// user scripts which could be copied between networks (tinc-up and tinc-down are generated for each network)
+var userScripts = []string{"host-up", "host-down", "subnet-up", "subnet-down"}
+
+// Rename network. Running network is stopped and started again with new name.
+// Autostart, restart policy and custom binary are moved to new name, interface name is kept.
+func (pool *Pool) Rename(oldName, newName string) (*network.Network, error) {
+	source, err := pool.Network(oldName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := pool.Network(newName)
+	if err != nil {
+		return nil, err
+	}
+	if !source.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", oldName)
+	}
+
+	pool.lock.Lock()
+	if _, err := os.Stat(target.Root); err == nil {
+		pool.lock.Unlock()
+		return nil, fmt.Errorf("network %s already exists", newName)
+	}
+	instance, running := pool.nets[oldName]
+	delete(pool.nets, oldName)
+	if running {
+		instance.Stop()
+		<-instance.Done()
+	}
+	err = pool.watcher.change(func() error {
+		return os.Rename(source.Root, target.Root)
+	}, source, target)
+	if err != nil {
+		pool.lock.Unlock()
+		return nil, fmt.Errorf("rename %s to %s: %w", oldName, newName, err)
+	}
+	if pool.Config.AutoStart.Has(oldName) {
+		pool.Config.AutoStart.Del(oldName)
+		pool.Config.AutoStart.Set(newName)
+	}
+	if policy, ok := pool.Config.Restart[oldName]; ok {
+		delete(pool.Config.Restart, oldName)
+		pool.Config.Restart[newName] = policy
+	}
+	if bin, ok := pool.Config.TincBin[oldName]; ok {
+		delete(pool.Config.TincBin, oldName)
+		pool.Config.TincBin[newName] = bin
+	}
+	err = pool.Config.Save()
+	pool.lock.Unlock()
+	if err != nil {
+		return target, err
+	}
+
+	if running {
+		if _, err := pool.RunNetwork(target); err != nil {
+			return target, fmt.Errorf("start renamed network: %w", err)
+		}
+	}
+	return target, nil
+}
+
+// Create new network based on settings of existent network: tinc.conf settings, user scripts and public addresses
+// are copied, keys and self node are generated. Empty subnet will be allocated automatically.
+func (pool *Pool) Clone(srcName, dstName string, subnet *net.IPNet) (*network.Network, error) {
+	source, err := pool.Network(srcName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := pool.Network(dstName)
+	if err != nil {
+		return nil, err
+	}
+	srcSelf, srcConfig, err := source.SelfConfig()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(target.Root); err == nil {
+		return nil, fmt.Errorf("network %s already exists", dstName)
+	}
+
+	ntw, err := pool.Create(dstName, subnet)
+	if err != nil {
+		return nil, err
+	}
+	err = pool.watcher.change(func() error {
+		return copySettings(source, ntw, srcSelf, srcConfig)
+	}, ntw)
+	if err != nil {
+		return nil, err
+	}
+	return ntw, nil
+}
+
+// copy tinc.conf settings, public addresses and user scripts of source network to target network
+func copySettings(source, ntw *network.Network, srcSelf *network.Node, srcConfig *network.Config) error {
+	config, err := ntw.Read()
+	if err != nil {
+		return err
+	}
+	config.Mode = srcConfig.Mode
+	config.DeviceType = srcConfig.DeviceType
+	config.Broadcast = srcConfig.Broadcast
+	if err := ntw.Update(config); err != nil {
+		return err
+	}
+
+	if len(srcSelf.Address) > 0 {
+		var addresses = make([]network.Address, 0, len(srcSelf.Address))
+		for _, addr := range srcSelf.Address {
+			if addr.Port == srcSelf.Port {
+				addr.Port = config.Port
+			}
+			addresses = append(addresses, addr)
+		}
+		if err := ntw.Upgrade(network.Upgrade{Address: addresses}); err != nil {
+			return err
+		}
+	}
+
+	if err := copyFiles(source.Root, ntw.Root, isUserScript); err != nil {
+		return err
+	}
+	return copyFiles(filepath.Join(source.Root, confDir), filepath.Join(ntw.Root, confDir), isConfFile)
+}
+
+// copy regular files accepted by filter
+func copyFiles(srcDir, dstDir string, filter func(filename string) bool) error {
+	list, err := ioutil.ReadDir(srcDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, info := range list {
+		if !info.Mode().IsRegular() || !filter(info.Name()) {
+			continue
+		}
+		if err := os.MkdirAll(dstDir, 0755); err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(filepath.Join(srcDir, info.Name()))
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dstDir, info.Name()), data, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", info.Name(), err)
+		}
+	}
+	return nil
+}
+
+func isUserScript(filename string) bool {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for _, script := range userScripts {
+		if name == script {
+			return true
+		}
+	}
+	return false
+}
+
+func isConfFile(filename string) bool {
+	return filepath.Ext(filename) == ".conf"
+}
@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+type RestartMode string
+
+const (
+	RestartNever     RestartMode = "never"
+	RestartOnFailure RestartMode = "on-failure"
+	RestartAlways    RestartMode = "always"
+)
+
+const (
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = time.Minute
+	defaultMaxRestarts = 5
+	defaultWindow      = 10 * time.Minute
+)
+
+// Policy of tincd restarts after unexpected exit. Empty fields are replaced by defaults
+type RestartPolicy struct {
+	Mode        RestartMode   `json:"mode"`                   // never, on-failure (default) or always
+	MinBackoff  time.Duration `json:"min_backoff,omitempty"`  // delay before first restart
+	MaxBackoff  time.Duration `json:"max_backoff,omitempty"`  // maximum delay between restarts
+	MaxRestarts int           `json:"max_restarts,omitempty"` // maximum number of restarts in window, negative means unlimited
+	Window      time.Duration `json:"window,omitempty"`       // window to count restarts
+}
+
+func (policy RestartPolicy) Validate() error {
+	switch policy.Mode {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("unknown restart mode %s", policy.Mode)
+	}
+	if policy.MinBackoff < 0 || policy.MaxBackoff < 0 || policy.Window < 0 {
+		return fmt.Errorf("negative intervals are not allowed")
+	}
+	if policy.MaxBackoff != 0 && policy.MaxBackoff < policy.MinBackoff {
+		return fmt.Errorf("max backoff should be greater or equal to min backoff")
+	}
+	return nil
+}
+
+// Copy of policy with defaults in place of empty fields
+func (policy RestartPolicy) Normalize() RestartPolicy {
+	if policy.Mode == "" {
+		policy.Mode = RestartOnFailure
+	}
+	if policy.MinBackoff == 0 {
+		policy.MinBackoff = defaultMinBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = defaultMaxBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	if policy.MaxRestarts == 0 {
+		policy.MaxRestarts = defaultMaxRestarts
+	}
+	if policy.Window == 0 {
+		policy.Window = defaultWindow
+	}
+	return policy
+}
+
+// Check that instance should be restarted after exit with the error
+func (policy RestartPolicy) ShouldRestart(exitErr error) bool {
+	switch policy.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure, "":
+		return exitErr != nil
+	default:
+		return false
+	}
+}
+
+// Next delay before restart: doubled previous delay limited by min and max backoff
+func (policy RestartPolicy) Backoff(previous time.Duration) time.Duration {
+	next := previous * 2
+	if next < policy.MinBackoff {
+		next = policy.MinBackoff
+	}
+	if next > policy.MaxBackoff {
+		next = policy.MaxBackoff
+	}
+	return next
+}
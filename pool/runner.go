@@ -0,0 +1,33 @@
+package pool
+
+import (
+	"context"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+)
+
+// Backend which starts tincd instances for networks
+type Runner interface {
+	// Start instance of network using binary. Not blocking after start
+	Start(ctx context.Context, ntw *network.Network, bin string) (tincd.Tincd, error)
+}
+
+// Optional interface of instance which could reload configuration without restart
+type Reloader interface {
+	Reload() error
+}
+
+// Optional interface of instance which knows its process ID
+type ProcessIdentifier interface {
+	PID() int
+}
+
+// Function as a runner
+type RunnerFunc func(ctx context.Context, ntw *network.Network, bin string) (tincd.Tincd, error)
+
+func (fn RunnerFunc) Start(ctx context.Context, ntw *network.Network, bin string) (tincd.Tincd, error) {
+	return fn(ctx, ntw, bin)
+}
+
+// Default runner: starts real tincd processes
+var TincdRunner Runner = RunnerFunc(startTincd)
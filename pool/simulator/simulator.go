@@ -0,0 +1,217 @@
+// Package simulator provides in-process runner of tinc networks without real tincd, privileges and TUN/TAP devices.
+// Peers joining and leaving as well as crashes of processes are raised on command.
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"sort"
+	"sync"
+)
+
+// Runner of simulated processes. Zero value is ready to use
+type Runner struct {
+	lock      sync.Mutex
+	lastPID   int
+	processes map[string]*Process // network name -> last started process
+	failures  map[string]error    // network name -> error for next starts
+	started   map[string]int      // network name -> number of starts
+}
+
+// Start simulated process of network. Network should be defined
+func (runner *Runner) Start(ctx context.Context, ntw *network.Network, bin string) (tincd.Tincd, error) {
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", ntw.Name())
+	}
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	if err := runner.failures[ntw.Name()]; err != nil {
+		return nil, err
+	}
+	if runner.processes == nil {
+		runner.processes = make(map[string]*Process)
+		runner.started = make(map[string]int)
+	}
+	runner.lastPID++
+	child, cancel := context.WithCancel(ctx)
+	proc := &Process{
+		pid:        runner.lastPID,
+		binary:     bin,
+		definition: ntw,
+		stop:       cancel,
+		done:       make(chan struct{}),
+		peers:      make(map[string]bool),
+	}
+	go proc.run(child)
+	runner.processes[ntw.Name()] = proc
+	runner.started[ntw.Name()]++
+	return proc, nil
+}
+
+// Last started process of network (nil if never started)
+func (runner *Runner) Process(network string) *Process {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	return runner.processes[network]
+}
+
+// Number of starts of network processes
+func (runner *Runner) Starts(network string) int {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	return runner.started[network]
+}
+
+// Make next starts of network fail with the error. Nil error clears failure
+func (runner *Runner) FailStart(network string, err error) {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+	if runner.failures == nil {
+		runner.failures = make(map[string]error)
+	}
+	if err == nil {
+		delete(runner.failures, network)
+		return
+	}
+	runner.failures[network] = err
+}
+
+// Simulated tincd process. Implements tincd.Tincd
+type Process struct {
+	pid        int
+	binary     string
+	definition *network.Network
+	events     network.Events
+	stop       func()
+	done       chan struct{}
+
+	lock    sync.RWMutex
+	peers   map[string]bool
+	err     error
+	reloads int
+}
+
+func (proc *Process) run(ctx context.Context) {
+	<-ctx.Done()
+	proc.lock.Lock()
+	if proc.err == nil {
+		// same as real instance
+		proc.err = ctx.Err()
+	}
+	peers := proc.peersUnsafe()
+	proc.peers = map[string]bool{}
+	proc.lock.Unlock()
+	for _, peer := range peers {
+		proc.events.PeerLeft.Emit(proc.peerID(peer))
+	}
+	close(proc.done)
+	proc.events.Stopped.Emit(network.NetworkID{Name: proc.definition.Name()})
+}
+
+// Connect peer to the network: emits PeerDiscovered (for new peer) and PeerJoined
+func (proc *Process) Join(node string) {
+	proc.lock.Lock()
+	_, known := proc.peers[node]
+	proc.peers[node] = true
+	proc.lock.Unlock()
+	if !known {
+		proc.events.PeerDiscovered.Emit(proc.peerID(node))
+	}
+	proc.events.PeerJoined.Emit(proc.peerID(node))
+}
+
+// Disconnect peer from the network: emits PeerLeft if peer was connected
+func (proc *Process) Leave(node string) {
+	proc.lock.Lock()
+	active := proc.peers[node]
+	delete(proc.peers, node)
+	proc.lock.Unlock()
+	if active {
+		proc.events.PeerLeft.Emit(proc.peerID(node))
+	}
+}
+
+// Terminate process with the error as like it crashed. Blocks till process exits
+func (proc *Process) Crash(err error) {
+	proc.lock.Lock()
+	if proc.err == nil {
+		proc.err = err
+	}
+	proc.lock.Unlock()
+	proc.stop()
+	<-proc.done
+}
+
+// Process ID (unique within runner)
+func (proc *Process) PID() int { return proc.pid }
+
+// Binary used to start process
+func (proc *Process) Binary() string { return proc.binary }
+
+// Reload configuration without restart
+func (proc *Process) Reload() error {
+	if !proc.IsRunning() {
+		return fmt.Errorf("process %d is not running", proc.pid)
+	}
+	proc.lock.Lock()
+	defer proc.lock.Unlock()
+	proc.reloads++
+	return nil
+}
+
+// Number of configuration reloads
+func (proc *Process) Reloads() int {
+	proc.lock.RLock()
+	defer proc.lock.RUnlock()
+	return proc.reloads
+}
+
+func (proc *Process) Events() *network.Events { return &proc.events }
+
+func (proc *Process) Stop() { proc.stop() }
+
+func (proc *Process) Error() error {
+	proc.lock.RLock()
+	defer proc.lock.RUnlock()
+	return proc.err
+}
+
+func (proc *Process) Done() <-chan struct{} { return proc.done }
+
+func (proc *Process) IsRunning() bool {
+	select {
+	case <-proc.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (proc *Process) IsActive(node string) bool {
+	proc.lock.RLock()
+	defer proc.lock.RUnlock()
+	return proc.peers[node]
+}
+
+func (proc *Process) Peers() []string {
+	proc.lock.RLock()
+	defer proc.lock.RUnlock()
+	return proc.peersUnsafe()
+}
+
+func (proc *Process) Definition() *network.Network { return proc.definition }
+
+func (proc *Process) peersUnsafe() []string {
+	var ans = make([]string, 0, len(proc.peers))
+	for peer := range proc.peers {
+		ans = append(ans, peer)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func (proc *Process) peerID(node string) network.PeerID {
+	return network.PeerID{Network: proc.definition.Name(), Node: node}
+}
@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtime status of network
+type Status struct {
+	Network     string        `json:"network"`
+	Running     bool          `json:"running"`
+	PID         int           `json:"pid,omitempty"`        // process ID of tincd (from pid file)
+	Started     *time.Time    `json:"started,omitempty"`    // last (re)start time of tincd
+	Uptime      time.Duration `json:"uptime,omitempty"`     // time since last (re)start
+	Restarts    int           `json:"restarts"`             // restarts made by supervisor
+	LastError   string        `json:"last_error,omitempty"` // last exit error of tincd
+	Interface   string        `json:"interface,omitempty"`  // network interface name
+	Addresses   []string      `json:"addresses,omitempty"`  // addresses assigned to the interface
+	Port        uint16        `json:"port"`                 // listening port
+	KnownPeers  int           `json:"known_peers"`          // number of defined nodes (except self)
+	OnlinePeers int           `json:"online_peers"`         // number of connected nodes
+}
+
+// information about last finished supervisor
+type exitInfo struct {
+	err      error
+	restarts int
+}
+
+// Runtime status of network. For stopped network information about last run is used
+func (pool *Pool) Status(name string) (*Status, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", name)
+	}
+	self, config, err := ntw.SelfConfig()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := ntw.Nodes()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var status = &Status{
+		Network:   name,
+		Interface: config.Interface,
+		Port:      self.Port,
+	}
+	for _, node := range nodes {
+		if node != config.Name {
+			status.KnownPeers++
+		}
+	}
+
+	pool.lock.Lock()
+	sv, running := pool.nets[name]
+	last := pool.exits[name]
+	pool.lock.Unlock()
+
+	if !running {
+		status.Restarts = last.restarts
+		status.LastError = exitError(last.err)
+		return status, nil
+	}
+
+	status.Running = sv.IsRunning()
+	status.Restarts = sv.Restarts()
+	status.LastError = exitError(sv.Error())
+	status.OnlinePeers = len(sv.Peers())
+	if started := sv.Started(); !started.IsZero() {
+		status.Started = &started
+		status.Uptime = time.Since(started).Truncate(time.Second)
+	}
+	if status.Running {
+		if pid, err := sv.PID(); err == nil {
+			status.PID = pid
+		}
+		status.Addresses = interfaceAddresses(config.Interface)
+	}
+	return status, nil
+}
+
+func readPid(ntw *network.Network) (int, error) {
+	data, err := ioutil.ReadFile(ntw.Pidfile())
+	if err != nil {
+		return 0, fmt.Errorf("read pid file: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty pid file")
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, fmt.Errorf("parse pid: %w", err)
+	}
+	return pid, nil
+}
+
+func interfaceAddresses(name string) []string {
+	if name == "" {
+		return nil
+	}
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	var ans = make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ans = append(ans, addr.String())
+	}
+	return ans
+}
+
+// stop by request is not an error
+func exitError(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return ""
+	}
+	return errorString(err)
+}
@@ -0,0 +1,256 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+	"sync"
+	"time"
+)
+
+type starterFunc func(ctx context.Context, ntw *network.Network) (tincd.Tincd, error)
+
+// Supervised tincd instance: restarts tincd according to restart policy.
+// Implements tincd.Tincd interface and proxies calls to the current instance.
+type supervisor struct {
+	definition *network.Network
+	start      starterFunc
+	lifecycle  *Lifecycle
+	events     network.Events
+	ctx        context.Context
+	stop       func()
+	done       chan struct{}
+
+	lock     sync.RWMutex
+	policy   RestartPolicy
+	current  tincd.Tincd
+	started  time.Time
+	err      error
+	restarts int
+}
+
+func newSupervisor(ctx context.Context, ntw *network.Network, policy RestartPolicy, lifecycle *Lifecycle, start starterFunc) *supervisor {
+	child, cancel := context.WithCancel(ctx)
+	return &supervisor{
+		definition: ntw,
+		start:      start,
+		lifecycle:  lifecycle,
+		ctx:        child,
+		stop:       cancel,
+		done:       make(chan struct{}),
+		policy:     policy,
+	}
+}
+
+// Start first instance and supervise it in background. Error returned only if first start failed
+func (sv *supervisor) Start() error {
+	instance, err := sv.start(sv.ctx, sv.definition)
+	if err != nil {
+		sv.stop()
+		return err
+	}
+	sv.attach(instance)
+	go sv.run(instance)
+	return nil
+}
+
+func (sv *supervisor) run(instance tincd.Tincd) {
+	defer close(sv.done)
+	defer sv.events.Stopped.Emit(network.NetworkID{Name: sv.definition.Name()})
+	defer sv.stop()
+
+	var (
+		backoff time.Duration
+		history []time.Time
+		started = time.Now()
+	)
+
+	for {
+		if instance != nil {
+			select {
+			case <-instance.Done():
+			case <-sv.ctx.Done():
+				instance.Stop()
+				<-instance.Done()
+			}
+			sv.setError(instance.Error())
+		}
+		if sv.ctx.Err() != nil {
+			return
+		}
+		policy := sv.Policy()
+		exitErr := sv.Error()
+		if !policy.ShouldRestart(exitErr) {
+			return
+		}
+
+		now := time.Now()
+		if time.Since(started) > policy.MaxBackoff {
+			// instance worked long enough to consider it stable
+			backoff = 0
+		}
+		history = pruneBefore(history, now.Add(-policy.Window))
+		if policy.MaxRestarts > 0 && len(history) >= policy.MaxRestarts {
+			log.Println(sv.definition.Name(), "too many restarts, giving up")
+			sv.lifecycle.GaveUp.Emit(GaveUp{
+				Network:  sv.definition.Name(),
+				Restarts: sv.Restarts(),
+				Reason:   errorString(exitErr),
+			})
+			return
+		}
+		backoff = policy.Backoff(backoff)
+		history = append(history, now)
+
+		sv.lifecycle.Restarting.Emit(Restarting{
+			Network: sv.definition.Name(),
+			Attempt: len(history),
+			Delay:   backoff,
+			Reason:  errorString(exitErr),
+		})
+		select {
+		case <-time.After(backoff):
+		case <-sv.ctx.Done():
+			return
+		}
+
+		started = time.Now()
+		instance, exitErr = sv.start(sv.ctx, sv.definition)
+		sv.lock.Lock()
+		sv.restarts++
+		sv.lock.Unlock()
+		if exitErr != nil {
+			log.Println(sv.definition.Name(), "restart failed:", exitErr)
+			instance = nil
+			sv.setError(exitErr)
+			continue
+		}
+		sv.attach(instance)
+	}
+}
+
+func (sv *supervisor) attach(instance tincd.Tincd) {
+	events := instance.Events()
+	events.PeerDiscovered.Subscribe(sv.events.PeerDiscovered.Emit)
+	events.PeerJoined.Subscribe(sv.events.PeerJoined.Emit)
+	events.PeerLeft.Subscribe(sv.events.PeerLeft.Emit)
+	sv.lock.Lock()
+	sv.current = instance
+	sv.started = time.Now()
+	sv.lock.Unlock()
+}
+
+func (sv *supervisor) instance() tincd.Tincd {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.current
+}
+
+func (sv *supervisor) setError(err error) {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	sv.err = err
+}
+
+// Current restart policy
+func (sv *supervisor) Policy() RestartPolicy {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.policy
+}
+
+// Update restart policy. Will be applied after next exit
+func (sv *supervisor) SetPolicy(policy RestartPolicy) {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	sv.policy = policy
+}
+
+// Number of restarts made by supervisor
+func (sv *supervisor) Restarts() int {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.restarts
+}
+
+// Time of last successful (re)start
+func (sv *supervisor) Started() time.Time {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.started
+}
+
+// Reload configuration of current instance without restart
+func (sv *supervisor) Reload() error {
+	instance := sv.instance()
+	if instance == nil {
+		return fmt.Errorf("network %s is not running", sv.definition.Name())
+	}
+	if reloader, ok := instance.(Reloader); ok {
+		return reloader.Reload()
+	}
+	return reloadTincd(sv.definition)
+}
+
+// Process ID of current instance
+func (sv *supervisor) PID() (int, error) {
+	instance := sv.instance()
+	if instance == nil {
+		return 0, fmt.Errorf("network %s is not running", sv.definition.Name())
+	}
+	if identifier, ok := instance.(ProcessIdentifier); ok {
+		return identifier.PID(), nil
+	}
+	return readPid(sv.definition)
+}
+
+func (sv *supervisor) Events() *network.Events { return &sv.events }
+
+func (sv *supervisor) Stop() { sv.stop() }
+
+func (sv *supervisor) Error() error {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.err
+}
+
+func (sv *supervisor) Done() <-chan struct{} { return sv.done }
+
+func (sv *supervisor) IsRunning() bool {
+	instance := sv.instance()
+	return instance != nil && instance.IsRunning()
+}
+
+func (sv *supervisor) IsActive(node string) bool {
+	instance := sv.instance()
+	return instance != nil && instance.IsActive(node)
+}
+
+func (sv *supervisor) Peers() []string {
+	instance := sv.instance()
+	if instance == nil {
+		return nil
+	}
+	return instance.Peers()
+}
+
+func (sv *supervisor) Definition() *network.Network { return sv.definition }
+
+func pruneBefore(list []time.Time, limit time.Time) []time.Time {
+	var ans = list[:0]
+	for _, t := range list {
+		if t.After(limit) {
+			ans = append(ans, t)
+		}
+	}
+	return ans
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
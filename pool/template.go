@@ -0,0 +1,202 @@
+package pool
+
+import (
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	confDir          = "conf.d"
+	templateConfFile = "template.conf"
+	maxCompression   = 11
+)
+
+
+// Template of network settings used for network creation
+type Template struct {
+	Name        string            `json:"name"`
+	Subnet      string            `json:"subnet,omitempty"`      // default subnet (CIDR)
+	DeviceType  string            `json:"device_type,omitempty"` // device type (tap, tun, ...)
+	Mode        string            `json:"mode,omitempty"`        // router, switch or hub
+	MinPort     uint16            `json:"min_port,omitempty"`    // lowest listening port (inclusive)
+	MaxPort     uint16            `json:"max_port,omitempty"`    // highest listening port (inclusive)
+	Address     []network.Address `json:"address,omitempty"`     // public addresses
+	Compression int               `json:"compression,omitempty"` // compression level (0-11), stored in conf.d (tincd 1.1+)
+	Scripts     map[string]string `json:"scripts,omitempty"`     // user scripts (host-up, subnet-up, ...) content by name
+}
+
+func (tpl *Template) Validate() error {
+	if !network.IsValidName(tpl.Name) {
+		return fmt.Errorf("invalid template name")
+	}
+	if tpl.Subnet != "" {
+		if _, _, err := net.ParseCIDR(tpl.Subnet); err != nil {
+			return fmt.Errorf("parse subnet: %w", err)
+		}
+	}
+	switch tpl.Mode {
+	case "", "router", "switch", "hub":
+	default:
+		return fmt.Errorf("unknown mode %s", tpl.Mode)
+	}
+	if tpl.MaxPort != 0 && tpl.MaxPort < tpl.MinPort {
+		return fmt.Errorf("max port should be greater or equal to min port")
+	}
+	if tpl.Compression < 0 || tpl.Compression > maxCompression {
+		return fmt.Errorf("compression level should be between 0 and %d", maxCompression)
+	}
+	for name := range tpl.Scripts {
+		if !isUserScript(name) || filepath.Base(name) != name {
+			return fmt.Errorf("unsupported script %s", name)
+		}
+	}
+	return nil
+}
+
+// Listening port from the range or 0 if range not defined
+func (tpl *Template) port() uint16 {
+	if tpl.MinPort == 0 {
+		return 0
+	}
+	if tpl.MaxPort <= tpl.MinPort {
+		return tpl.MinPort
+	}
+	return tpl.MinPort + uint16(rand.Int63n(int64(tpl.MaxPort-tpl.MinPort)+1))
+}
+
+// Stored templates sorted by name
+func (pool *Pool) Templates() []*Template {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	var ans = make([]*Template, 0, len(pool.Config.Templates))
+	for _, tpl := range pool.Config.Templates {
+		ans = append(ans, tpl)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Name < ans[j].Name
+	})
+	return ans
+}
+
+// Template by name
+func (pool *Pool) Template(name string) (*Template, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	tpl, ok := pool.Config.Templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return tpl, nil
+}
+
+// Create or replace template
+func (pool *Pool) SaveTemplate(tpl Template) error {
+	if err := tpl.Validate(); err != nil {
+		return err
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if pool.Config.Templates == nil {
+		pool.Config.Templates = make(map[string]*Template)
+	}
+	pool.Config.Templates[tpl.Name] = &tpl
+	return pool.Config.Save()
+}
+
+// Remove template. Returns true if template existed
+func (pool *Pool) RemoveTemplate(name string) (bool, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if _, ok := pool.Config.Templates[name]; !ok {
+		return false, nil
+	}
+	delete(pool.Config.Templates, name)
+	return true, pool.Config.Save()
+}
+
+// Create new network using template. Empty subnet means subnet from template or automatically allocated subnet
+func (pool *Pool) CreateFromTemplate(name string, subnet *net.IPNet, template string) (*network.Network, error) {
+	tpl, err := pool.Template(template)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.Compression > 0 {
+		// tincd 1.0 reads only tinc.conf which is re-generated by the daemon
+		if version, err := pool.binaryVersion(pool.binaryFor(name)); err == nil && strings.HasPrefix(version, "1.0") {
+			return nil, fmt.Errorf("apply template %s: compression is not supported by tincd %s", template, version)
+		}
+	}
+	if subnet == nil && tpl.Subnet != "" {
+		_, subnet, err = net.ParseCIDR(tpl.Subnet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	target, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(target.Root); err == nil {
+		return nil, fmt.Errorf("network %s already exists", name)
+	}
+
+	ntw, err := pool.Create(name, subnet)
+	if err != nil {
+		return nil, err
+	}
+	err = pool.watcher.change(func() error {
+		return tpl.apply(ntw)
+	}, ntw)
+	if err != nil {
+		return nil, fmt.Errorf("apply template %s: %w", template, err)
+	}
+	return ntw, nil
+}
+
+func (tpl *Template) apply(ntw *network.Network) error {
+	config, err := ntw.Read()
+	if err != nil {
+		return err
+	}
+	if tpl.Mode != "" {
+		config.Mode = tpl.Mode
+	}
+	if tpl.DeviceType != "" {
+		config.DeviceType = tpl.DeviceType
+	}
+	if err := ntw.Update(config); err != nil {
+		return err
+	}
+
+	var upgrade = network.Upgrade{Port: tpl.port(), Address: tpl.Address}
+	if upgrade.Port != 0 || len(upgrade.Address) > 0 {
+		if err := ntw.Upgrade(upgrade); err != nil {
+			return err
+		}
+	}
+
+	for name, content := range tpl.Scripts {
+		if err := ioutil.WriteFile(filepath.Join(ntw.Root, name), []byte(content), 0755); err != nil {
+			return err
+		}
+	}
+
+	if tpl.Compression > 0 {
+		// tinc.conf is re-generated on each update, so additional options are kept in conf.d (read by tincd 1.1+)
+		if err := os.MkdirAll(filepath.Join(ntw.Root, confDir), 0755); err != nil {
+			return err
+		}
+		data := []byte(fmt.Sprintf("Compression = %d\n", tpl.Compression))
+		if err := ioutil.WriteFile(filepath.Join(ntw.Root, confDir, templateConfFile), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	trashDir              = ".trash"
+	defaultTrashRetention = 7 * 24 * time.Hour
+)
+
+// Removed network that could be restored till expiration
+type TrashItem struct {
+	ID      string    `json:"id"`
+	Network string    `json:"network"`
+	Removed time.Time `json:"removed"`
+	Expires time.Time `json:"expires"`
+}
+
+// Removed networks sorted by removal time (newest first). Expired items are purged
+func (pool *Pool) Trash() ([]*TrashItem, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.listTrash()
+}
+
+// Restore removed network by trash item ID. Fails if network with same name already exists or item is expired
+func (pool *Pool) Restore(id string) (*network.Network, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	item, err := parseTrashItem(id, pool.trashRetention())
+	if err != nil {
+		return nil, err
+	}
+	if item.Expires.Before(time.Now()) {
+		// expired item could be not purged yet
+		if _, err := pool.listTrash(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("trash item %s is expired", item.ID)
+	}
+	ntw := &network.Network{Root: filepath.Join(pool.rootDir, item.Network)}
+	if _, err := os.Stat(ntw.Root); err == nil {
+		return nil, fmt.Errorf("network %s already exists", item.Network)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	err = pool.watcher.change(func() error {
+		return os.Rename(filepath.Join(pool.trashLocation(), item.ID), ntw.Root)
+	}, ntw)
+	if err != nil {
+		return nil, fmt.Errorf("restore %s: %w", item.ID, err)
+	}
+	return ntw, nil
+}
+
+// moves network directory to trash, should be called under lock
+func (pool *Pool) moveToTrash(name string) (bool, error) {
+	location := filepath.Join(pool.rootDir, name)
+	if _, err := os.Stat(location); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(pool.trashLocation(), 0755); err != nil {
+		return false, err
+	}
+	id := name + "." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := os.Rename(location, filepath.Join(pool.trashLocation(), id)); err != nil {
+		return false, fmt.Errorf("move %s to trash: %w", name, err)
+	}
+	return true, nil
+}
+
+// lists trash and purges expired items, should be called under lock
+func (pool *Pool) listTrash() ([]*TrashItem, error) {
+	list, err := ioutil.ReadDir(pool.trashLocation())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ans []*TrashItem
+	now := time.Now()
+	for _, info := range list {
+		item, err := parseTrashItem(info.Name(), pool.trashRetention())
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if item.Expires.Before(now) {
+			if err := os.RemoveAll(filepath.Join(pool.trashLocation(), item.ID)); err != nil {
+				log.Println("purge", item.ID, "from trash:", err)
+			}
+			continue
+		}
+		ans = append(ans, item)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Removed.After(ans[j].Removed)
+	})
+	return ans, nil
+}
+
+func (pool *Pool) trashLocation() string {
+	return filepath.Join(pool.rootDir, trashDir)
+}
+
+func (pool *Pool) trashRetention() time.Duration {
+	if pool.Config.TrashRetention > 0 {
+		return pool.Config.TrashRetention
+	}
+	return defaultTrashRetention
+}
+
+func parseTrashItem(id string, retention time.Duration) (*TrashItem, error) {
+	idx := strings.LastIndex(id, ".")
+	if idx == -1 {
+		return nil, fmt.Errorf("invalid trash item ID")
+	}
+	name := id[:idx]
+	if !network.IsValidName(name) {
+		return nil, fmt.Errorf("invalid trash item ID")
+	}
+	stamp, err := strconv.ParseInt(id[idx+1:], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trash item ID")
+	}
+	removed := time.Unix(0, stamp)
+	return &TrashItem{
+		ID:      id,
+		Network: name,
+		Removed: removed,
+		Expires: removed.Add(retention),
+	}, nil
+}
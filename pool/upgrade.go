@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"github.com/tinc-boot/tincd/network"
+	"reflect"
+)
+
+// Result of self node upgrade
+type UpgradeResult struct {
+	Node            *network.Node `json:"node"`             // upgraded self node
+	RestartRequired bool          `json:"restart_required"` // changes could be applied only by restart (running network is restarted)
+	Reloaded        bool          `json:"reloaded"`         // running network reloaded configuration without restart
+}
+
+// Upgrade self node parameters and apply changes to running network.
+// Port and device could be changed only by restart, other changes are applied by reload.
+func (pool *Pool) Upgrade(name string, upgrade network.Upgrade) (*UpgradeResult, error) {
+	ntw, err := pool.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	selfBefore, before, err := ntw.SelfConfig()
+	if err != nil {
+		return nil, err
+	}
+	err = pool.watcher.change(func() error {
+		return ntw.Upgrade(upgrade)
+	}, ntw)
+	if err != nil {
+		return nil, err
+	}
+	self, after, err := ntw.SelfConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var result = &UpgradeResult{
+		Node:            self,
+		RestartRequired: before.Port != after.Port || before.Device != after.Device,
+	}
+	changed := result.RestartRequired || !reflect.DeepEqual(selfBefore.Address, self.Address)
+	if !changed || !pool.IsRunning(name) {
+		return result, nil
+	}
+	if result.RestartRequired {
+		return result, pool.Restart(name)
+	}
+	reloaded, err := pool.reload(name)
+	if err != nil {
+		return result, err
+	}
+	result.Reloaded = reloaded
+	result.RestartRequired = !reloaded
+	return result, nil
+}
@@ -0,0 +1,190 @@
+package pool
+
+import (
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+// Reconciliation of networks directory with changes made outside of the daemon
+type WatchConfig struct {
+	Disabled bool          `json:"disabled,omitempty"` // do not watch networks directory
+	Interval time.Duration `json:"interval,omitempty"` // interval between directory scans
+	Reload   bool          `json:"reload,omitempty"`   // reload running tincd after hosts changes
+}
+
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// known state of networks directory: network name -> host name -> file stamp
+type dirState map[string]map[string]fileStamp
+
+type watcher struct {
+	lock  sync.Mutex
+	known dirState
+}
+
+// scan networks directory and return differences with previously known state
+func (w *watcher) scan(rootDir string) ([]NetworkChanged, []NetworkChanged, []HostsChanged, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	current, err := scanNetworks(rootDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var (
+		added   []NetworkChanged
+		removed []NetworkChanged
+		hosts   []HostsChanged
+	)
+	for name, currentHosts := range current {
+		knownHosts, ok := w.known[name]
+		if !ok {
+			added = append(added, NetworkChanged{Network: name})
+			continue
+		}
+		if diff, changed := diffHosts(name, knownHosts, currentHosts); changed {
+			hosts = append(hosts, diff)
+		}
+	}
+	for name := range w.known {
+		if _, ok := current[name]; !ok {
+			removed = append(removed, NetworkChanged{Network: name})
+		}
+	}
+	w.known = current
+	return added, removed, hosts, nil
+}
+
+// make change of networks by daemon itself: directory is not scanned during change and known state
+// of affected networks is silently updated after it
+func (w *watcher) change(fn func() error, networks ...*network.Network) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	err := fn()
+	for _, ntw := range networks {
+		w.sync(ntw)
+	}
+	return err
+}
+
+// update known state of single network, should be called under lock
+func (w *watcher) sync(ntw *network.Network) {
+	if w.known == nil {
+		return
+	}
+	if !ntw.IsDefined() {
+		delete(w.known, ntw.Name())
+		return
+	}
+	hosts, err := scanHosts(ntw)
+	if err != nil {
+		delete(w.known, ntw.Name())
+		return
+	}
+	w.known[ntw.Name()] = hosts
+}
+
+func (pool *Pool) watch(config WatchConfig) {
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		added, removed, hosts, err := pool.watcher.scan(pool.rootDir)
+		if err != nil {
+			log.Println("scan networks:", err)
+			continue
+		}
+		for _, event := range added {
+			pool.life.NetworkAdded.Emit(event)
+		}
+		for _, event := range removed {
+			pool.life.NetworkRemoved.Emit(event)
+		}
+		for _, event := range hosts {
+			if config.Reload && pool.IsRunning(event.Network) {
+				if err := pool.Reload(event.Network); err != nil {
+					log.Println("reload", event.Network, ":", err)
+				} else {
+					event.Reloaded = true
+				}
+			}
+			pool.life.HostsChanged.Emit(event)
+		}
+	}
+}
+
+func scanNetworks(rootDir string) (dirState, error) {
+	list, err := network.List(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	var state = make(dirState, len(list))
+	for _, ntw := range list {
+		if !ntw.IsDefined() {
+			continue
+		}
+		hosts, err := scanHosts(ntw)
+		if err != nil {
+			return nil, err
+		}
+		state[ntw.Name()] = hosts
+	}
+	return state, nil
+}
+
+func scanHosts(ntw *network.Network) (map[string]fileStamp, error) {
+	list, err := ioutil.ReadDir(filepath.Join(ntw.Root, "hosts"))
+	if os.IsNotExist(err) {
+		return map[string]fileStamp{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hosts = make(map[string]fileStamp, len(list))
+	for _, info := range list {
+		if info.IsDir() {
+			continue
+		}
+		hosts[info.Name()] = fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}
+	}
+	return hosts, nil
+}
+
+func diffHosts(name string, known, current map[string]fileStamp) (HostsChanged, bool) {
+	var diff = HostsChanged{Network: name}
+	for host, stamp := range current {
+		old, ok := known[host]
+		if !ok {
+			diff.Added = append(diff.Added, host)
+		} else if old != stamp {
+			diff.Updated = append(diff.Updated, host)
+		}
+	}
+	for host := range known {
+		if _, ok := current[host]; !ok {
+			diff.Removed = append(diff.Removed, host)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Updated)
+	return diff, len(diff.Added)+len(diff.Removed)+len(diff.Updated) > 0
+}
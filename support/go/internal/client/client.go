@@ -0,0 +1,57 @@
+// Package client is JSON-RPC 2.0 over HTTP transport for generated Go clients with custom HTTP client
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/reddec/jsonrpc2"
+	"net/http"
+)
+
+type request struct {
+	Version string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	ID      interface{}   `json:"id"`
+	Params  []interface{} `json:"params"`
+}
+
+// Call JSON-RPC 2.0 over HTTP by provided client (nil - http.DefaultClient).
+// Same as CallHTTP from github.com/reddec/jsonrpc2/client except client.
+func CallHTTP(ctx context.Context, httpClient *http.Client, url string, method string, id interface{}, out interface{}, params ...interface{}) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	data, err := json.Marshal(&request{
+		Version: "2.0",
+		Method:  method,
+		ID:      id,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("%s [id=%v]: call over HTTP via %s, JSON encode: %w", method, id, url, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s [id=%v]: call over HTTP via %s, prepare request: %w", method, id, url, err)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s [id=%v]: call over HTTP via %s, make request: %w", method, id, url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s [id=%v]: call over HTTP via %s, request status code %d - %s", method, id, url, res.StatusCode, res.Status)
+	}
+	var reply jsonrpc2.Response
+	reply.Result = out
+	err = json.NewDecoder(res.Body).Decode(&reply)
+	if err != nil {
+		return fmt.Errorf("%s [id=%v]: call over HTTP via %s, parse response: %w", method, id, url, err)
+	}
+	if reply.Error != nil {
+		return reply.Error
+	}
+	return nil
+}
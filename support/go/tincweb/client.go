@@ -2,10 +2,11 @@ package tincweb
 
 import (
 	"context"
-	client "github.com/reddec/jsonrpc2/client"
 	network "github.com/tinc-boot/tincd/network"
+	"net/http"
 	"sync/atomic"
 	"time"
+	client "tinc-web-boot/support/go/internal/client"
 	shared "tinc-web-boot/web/shared"
 )
 
@@ -15,96 +16,299 @@ func Default() *TincWebClient {
 
 type TincWebClient struct {
 	BaseURL  string
+	HTTP     *http.Client // client for requests (nil - http.DefaultClient)
 	sequence uint64
 }
 
 // List of available networks (briefly, without config)
 func (impl *TincWebClient) Networks(ctx context.Context) (reply []*shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Networks", atomic.AddUint64(&impl.sequence, 1), &reply)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Networks", atomic.AddUint64(&impl.sequence, 1), &reply)
 	return
 }
 
 // Detailed network info
 func (impl *TincWebClient) Network(ctx context.Context, name string) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Network", atomic.AddUint64(&impl.sequence, 1), &reply, name)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Network", atomic.AddUint64(&impl.sequence, 1), &reply, name)
 	return
 }
 
-// Create new network if not exists
+/*
+Create new network if not exists. Empty subnet means automatically allocated free subnet.
+Subnet should not overlap with other networks and host routes
+*/
 func (impl *TincWebClient) Create(ctx context.Context, name string, subnet string) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Create", atomic.AddUint64(&impl.sequence, 1), &reply, name, subnet)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Create", atomic.AddUint64(&impl.sequence, 1), &reply, name, subnet)
+	return
+}
+
+/*
+Create new network if not exists with settings from template (or default settings if template name is empty).
+Empty subnet means subnet from template or automatically allocated free subnet
+*/
+func (impl *TincWebClient) CreateFromTemplate(ctx context.Context, name string, subnet string, template string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.CreateFromTemplate", atomic.AddUint64(&impl.sequence, 1), &reply, name, subnet, template)
+	return
+}
+
+// Rename network. Running network is restarted, autostart and interface name are kept
+func (impl *TincWebClient) Rename(ctx context.Context, network string, newName string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Rename", atomic.AddUint64(&impl.sequence, 1), &reply, network, newName)
+	return
+}
+
+/*
+Create new network with settings of existent network (keys and self node are generated).
+Empty subnet means automatically allocated free subnet
+*/
+func (impl *TincWebClient) Clone(ctx context.Context, source string, network string, subnet string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Clone", atomic.AddUint64(&impl.sequence, 1), &reply, source, network, subnet)
 	return
 }
 
-// Remove network (returns true if network existed)
+/*
+Remove network (returns true if network existed).
+Network is stopped and moved to trash, from where it could be restored during retention period
+*/
 func (impl *TincWebClient) Remove(ctx context.Context, network string) (reply bool, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Remove", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Remove", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Templates for new networks
+func (impl *TincWebClient) Templates(ctx context.Context) (reply []*shared.Template, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Templates", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Template by name
+func (impl *TincWebClient) Template(ctx context.Context, name string) (reply *shared.Template, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Template", atomic.AddUint64(&impl.sequence, 1), &reply, name)
+	return
+}
+
+// Create or replace template
+func (impl *TincWebClient) SaveTemplate(ctx context.Context, template shared.Template) (reply *shared.Template, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.SaveTemplate", atomic.AddUint64(&impl.sequence, 1), &reply, template)
+	return
+}
+
+// Remove template (returns true if template existed)
+func (impl *TincWebClient) RemoveTemplate(ctx context.Context, name string) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RemoveTemplate", atomic.AddUint64(&impl.sequence, 1), &reply, name)
+	return
+}
+
+// Removed networks that could be restored
+func (impl *TincWebClient) Trash(ctx context.Context) (reply []*shared.TrashItem, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Trash", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Restore removed network from trash by item ID
+func (impl *TincWebClient) Restore(ctx context.Context, id string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Restore", atomic.AddUint64(&impl.sequence, 1), &reply, id)
+	return
+}
+
+// Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+func (impl *TincWebClient) Backup(ctx context.Context) (reply *shared.Backup, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Backup", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+/*
+Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+Running networks are started again after restore
+*/
+func (impl *TincWebClient) RestoreBackup(ctx context.Context, archive []byte, options shared.RestoreOptions) (reply *shared.RestoreResult, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RestoreBackup", atomic.AddUint64(&impl.sequence, 1), &reply, archive, options)
 	return
 }
 
 // Start or re-start network
 func (impl *TincWebClient) Start(ctx context.Context, network string) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Start", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Start", atomic.AddUint64(&impl.sequence, 1), &reply, network)
 	return
 }
 
 // Stop network
 func (impl *TincWebClient) Stop(ctx context.Context, network string) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Stop", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Stop", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Runtime status of network: process, uptime, restarts, interface and peers
+func (impl *TincWebClient) Status(ctx context.Context, network string) (reply *shared.Status, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Status", atomic.AddUint64(&impl.sequence, 1), &reply, network)
 	return
 }
 
 // Peers brief list in network  (briefly, without config)
 func (impl *TincWebClient) Peers(ctx context.Context, network string) (reply []*shared.PeerInfo, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Peers", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Peers", atomic.AddUint64(&impl.sequence, 1), &reply, network)
 	return
 }
 
 // Peer detailed info by in the network
 func (impl *TincWebClient) Peer(ctx context.Context, network string, name string) (reply *shared.PeerInfo, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Peer", atomic.AddUint64(&impl.sequence, 1), &reply, network, name)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Peer", atomic.AddUint64(&impl.sequence, 1), &reply, network, name)
 	return
 }
 
 /*
 Import another tinc-web network configuration file.
 It means let nodes defined in config join to the network.
-Return created (or used) network with full configuration
+Return created (or used) network with full configuration.
+Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
 */
 func (impl *TincWebClient) Import(ctx context.Context, sharing shared.Sharing) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Import", atomic.AddUint64(&impl.sequence, 1), &reply, sharing)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Import", atomic.AddUint64(&impl.sequence, 1), &reply, sharing)
 	return
 }
 
-// Share network and generate configuration file.
+/*
+Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+(code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+Encrypted document is decrypted by passphrase from options before checks.
+Return created (or used) network with full configuration and signer of document
+*/
+func (impl *TincWebClient) ImportSigned(ctx context.Context, sharing shared.Sharing, options shared.ImportOptions) (reply *shared.Imported, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.ImportSigned", atomic.AddUint64(&impl.sequence, 1), &reply, sharing, options)
+	return
+}
+
+// Share network and generate configuration file signed by owner key of network.
 func (impl *TincWebClient) Share(ctx context.Context, network string) (reply *shared.Sharing, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Share", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Share", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Share network and generate signed configuration file encrypted by passphrase from options
+func (impl *TincWebClient) ShareEncrypted(ctx context.Context, network string, options shared.ShareOptions) (reply *shared.Sharing, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.ShareEncrypted", atomic.AddUint64(&impl.sequence, 1), &reply, network, options)
 	return
 }
 
 // Node definition in network (aka - self node)
 func (impl *TincWebClient) Node(ctx context.Context, network string) (reply *network.Node, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Node", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Node", atomic.AddUint64(&impl.sequence, 1), &reply, network)
 	return
 }
 
 /*
 Upgrade node parameters.
-In some cases requires restart
+Running network reloads configuration or restarts (if port or device changed) automatically
 */
-func (impl *TincWebClient) Upgrade(ctx context.Context, network string, update network.Upgrade) (reply *network.Node, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Upgrade", atomic.AddUint64(&impl.sequence, 1), &reply, network, update)
+func (impl *TincWebClient) Upgrade(ctx context.Context, network string, update network.Upgrade) (reply *shared.UpgradeResult, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Upgrade", atomic.AddUint64(&impl.sequence, 1), &reply, network, update)
 	return
 }
 
-// Generate Majordomo request for easy-sharing
+// Generate Majordomo request for easy-sharing (invitation without limits)
 func (impl *TincWebClient) Majordomo(ctx context.Context, network string, lifetime time.Duration) (reply string, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Majordomo", atomic.AddUint64(&impl.sequence, 1), &reply, network, lifetime)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Majordomo", atomic.AddUint64(&impl.sequence, 1), &reply, network, lifetime)
+	return
+}
+
+// Generate Majordomo link with limited number of uses and optional fixed node name
+func (impl *TincWebClient) Invite(ctx context.Context, network string, options shared.InviteOptions) (reply *shared.Invite, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Invite", atomic.AddUint64(&impl.sequence, 1), &reply, network, options)
+	return
+}
+
+// Invitations to network with redemptions
+func (impl *TincWebClient) Invitations(ctx context.Context, network string) (reply []*shared.Invitation, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Invitations", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Invitation to network by ID
+func (impl *TincWebClient) Invitation(ctx context.Context, network string, id string) (reply *shared.Invitation, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Invitation", atomic.AddUint64(&impl.sequence, 1), &reply, network, id)
+	return
+}
+
+// Revoke invitation, link will not work anymore. Returns true if invitation was active
+func (impl *TincWebClient) RevokeInvitation(ctx context.Context, network string, id string) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RevokeInvitation", atomic.AddUint64(&impl.sequence, 1), &reply, network, id)
+	return
+}
+
+// Requests to join network by invitations which require approval
+func (impl *TincWebClient) JoinRequests(ctx context.Context, network string) (reply []*shared.JoinRequest, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.JoinRequests", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Approve join request. Waiting node joins network on next poll
+func (impl *TincWebClient) ApproveJoin(ctx context.Context, network string, id string) (reply *shared.JoinRequest, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.ApproveJoin", atomic.AddUint64(&impl.sequence, 1), &reply, network, id)
 	return
 }
 
-// Join by Majordomo Link
+// Reject join request
+func (impl *TincWebClient) RejectJoin(ctx context.Context, network string, id string) (reply *shared.JoinRequest, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RejectJoin", atomic.AddUint64(&impl.sequence, 1), &reply, network, id)
+	return
+}
+
+/*
+Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+of inviting node (or rejected, or invitation expired)
+*/
 func (impl *TincWebClient) Join(ctx context.Context, url string, start bool) (reply *shared.Network, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWeb.Join", atomic.AddUint64(&impl.sequence, 1), &reply, url, start)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Join", atomic.AddUint64(&impl.sequence, 1), &reply, url, start)
+	return
+}
+
+// Start network automatically with tinc-web-boot
+func (impl *TincWebClient) EnableAutostart(ctx context.Context, network string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.EnableAutostart", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Do not start network automatically with tinc-web-boot
+func (impl *TincWebClient) DisableAutostart(ctx context.Context, network string) (reply *shared.Network, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.DisableAutostart", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Names of networks that will be started automatically
+func (impl *TincWebClient) AutostartNetworks(ctx context.Context) (reply []string, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.AutostartNetworks", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Effective policy of tincd restarts after unexpected exit
+func (impl *TincWebClient) RestartPolicy(ctx context.Context, network string) (reply *shared.RestartPolicy, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RestartPolicy", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+/*
+Set custom restart policy for the network. Empty mode resets policy to default.
+Applied immediately for running network
+*/
+func (impl *TincWebClient) SetRestartPolicy(ctx context.Context, network string, policy shared.RestartPolicy) (reply *shared.RestartPolicy, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.SetRestartPolicy", atomic.AddUint64(&impl.sequence, 1), &reply, network, policy)
+	return
+}
+
+// Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
+func (impl *TincWebClient) Leases(ctx context.Context, network string) (reply []*shared.Lease, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.Leases", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
+func (impl *TincWebClient) SetLease(ctx context.Context, network string, node string, ip string) (reply *shared.Lease, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.SetLease", atomic.AddUint64(&impl.sequence, 1), &reply, network, node, ip)
+	return
+}
+
+// Remove address lease of node (returns true if lease existed)
+func (impl *TincWebClient) RemoveLease(ctx context.Context, network string, node string) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWeb.RemoveLease", atomic.AddUint64(&impl.sequence, 1), &reply, network, node)
 	return
 }
@@ -2,9 +2,10 @@ package tincwebmajordomo
 
 import (
 	"context"
-	client "github.com/reddec/jsonrpc2/client"
 	network "github.com/tinc-boot/tincd/network"
+	"net/http"
 	"sync/atomic"
+	client "tinc-web-boot/support/go/internal/client"
 	shared "tinc-web-boot/web/shared"
 )
 
@@ -14,11 +15,17 @@ func Default() *TincWebMajordomoClient {
 
 type TincWebMajordomoClient struct {
 	BaseURL  string
+	HTTP     *http.Client // client for requests (nil - http.DefaultClient)
 	sequence uint64
 }
 
-// Join public network if code matched. Will generate error if node subnet not matched
+/*
+Join public network if code matched. Node receives free address inside network subnet
+(returned sharing contains node with assigned address).
+Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
+*/
 func (impl *TincWebMajordomoClient) Join(ctx context.Context, network string, self *network.Node) (reply *shared.Sharing, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWebMajordomo.Join", atomic.AddUint64(&impl.sequence, 1), &reply, network, self)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebMajordomo.Join", atomic.AddUint64(&impl.sequence, 1), &reply, network, self)
 	return
 }
@@ -2,8 +2,10 @@ package tincwebui
 
 import (
 	"context"
-	client "github.com/reddec/jsonrpc2/client"
+	"net/http"
 	"sync/atomic"
+	"time"
+	client "tinc-web-boot/support/go/internal/client"
 	shared "tinc-web-boot/web/shared"
 )
 
@@ -13,29 +15,69 @@ func Default() *TincWebUIClient {
 
 type TincWebUIClient struct {
 	BaseURL  string
+	HTTP     *http.Client // client for requests (nil - http.DefaultClient)
 	sequence uint64
 }
 
 // Issue and sign token
 func (impl *TincWebUIClient) IssueAccessToken(ctx context.Context, validDays uint) (reply string, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWebUI.IssueAccessToken", atomic.AddUint64(&impl.sequence, 1), &reply, validDays)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.IssueAccessToken", atomic.AddUint64(&impl.sequence, 1), &reply, validDays)
+	return
+}
+
+// Issue and sign token for role (admin, operator or viewer) with optional label
+func (impl *TincWebUIClient) IssueToken(ctx context.Context, role shared.Role, label string, validDays uint) (reply string, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.IssueToken", atomic.AddUint64(&impl.sequence, 1), &reply, role, label, validDays)
+	return
+}
+
+// Issued and not expired tokens including majordomo links
+func (impl *TincWebUIClient) ListTokens(ctx context.Context) (reply []*shared.Token, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.ListTokens", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Revoke token by ID. Returns true if token existed
+func (impl *TincWebUIClient) RevokeToken(ctx context.Context, id string) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.RevokeToken", atomic.AddUint64(&impl.sequence, 1), &reply, id)
+	return
+}
+
+// Active signing keys, first one is current
+func (impl *TincWebUIClient) SigningKeys(ctx context.Context) (reply []*shared.SigningKey, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.SigningKeys", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+/*
+Generate new signing key. Previous keys are valid for verification during grace period.
+Returns active signing keys
+*/
+func (impl *TincWebUIClient) RotateKey(ctx context.Context, grace time.Duration) (reply []*shared.SigningKey, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.RotateKey", atomic.AddUint64(&impl.sequence, 1), &reply, grace)
 	return
 }
 
 // Make desktop notification if system supports it
 func (impl *TincWebUIClient) Notify(ctx context.Context, title string, message string) (reply bool, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWebUI.Notify", atomic.AddUint64(&impl.sequence, 1), &reply, title, message)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.Notify", atomic.AddUint64(&impl.sequence, 1), &reply, title, message)
 	return
 }
 
 // Endpoints list to access web UI
 func (impl *TincWebUIClient) Endpoints(ctx context.Context) (reply []shared.Endpoint, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWebUI.Endpoints", atomic.AddUint64(&impl.sequence, 1), &reply)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.Endpoints", atomic.AddUint64(&impl.sequence, 1), &reply)
 	return
 }
 
 // Configuration defined for the instance
 func (impl *TincWebUIClient) Configuration(ctx context.Context) (reply *shared.Config, err error) {
-	err = client.CallHTTP(ctx, impl.BaseURL, "TincWebUI.Configuration", atomic.AddUint64(&impl.sequence, 1), &reply)
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.Configuration", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Tincd binaries (default and per-network) with detected versions
+func (impl *TincWebUIClient) Binaries(ctx context.Context) (reply []*shared.TincBinary, err error) {
+	err = client.CallHTTP(ctx, impl.HTTP, impl.BaseURL, "TincWebUI.Binaries", atomic.AddUint64(&impl.sequence, 1), &reply)
 	return
 }
@@ -45,7 +45,8 @@ export class TincWeb {
     }
 
     /**
-    Create new network if not exists
+    Create new network if not exists. Empty subnet means automatically allocated free subnet.
+Subnet should not overlap with other networks and host routes
     **/
     async create(name, subnet){
         return (await this.__call('Create', {
@@ -57,7 +58,46 @@ export class TincWeb {
     }
 
     /**
-    Remove network (returns true if network existed)
+    Create new network if not exists with settings from template (or default settings if template name is empty).
+Empty subnet means subnet from template or automatically allocated free subnet
+    **/
+    async createFromTemplate(name, subnet, template){
+        return (await this.__call('CreateFromTemplate', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.CreateFromTemplate",
+            "id" : this.__next_id(),
+            "params" : [name, subnet, template]
+        }));
+    }
+
+    /**
+    Rename network. Running network is restarted, autostart and interface name are kept
+    **/
+    async rename(network, newName){
+        return (await this.__call('Rename', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Rename",
+            "id" : this.__next_id(),
+            "params" : [network, newName]
+        }));
+    }
+
+    /**
+    Create new network with settings of existent network (keys and self node are generated).
+Empty subnet means automatically allocated free subnet
+    **/
+    async clone(source, network, subnet){
+        return (await this.__call('Clone', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Clone",
+            "id" : this.__next_id(),
+            "params" : [source, network, subnet]
+        }));
+    }
+
+    /**
+    Remove network (returns true if network existed).
+Network is stopped and moved to trash, from where it could be restored during retention period
     **/
     async remove(network){
         return (await this.__call('Remove', {
@@ -68,6 +108,103 @@ export class TincWeb {
         }));
     }
 
+    /**
+    Templates for new networks
+    **/
+    async templates(){
+        return (await this.__call('Templates', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Templates",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Template by name
+    **/
+    async template(name){
+        return (await this.__call('Template', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Template",
+            "id" : this.__next_id(),
+            "params" : [name]
+        }));
+    }
+
+    /**
+    Create or replace template
+    **/
+    async saveTemplate(template){
+        return (await this.__call('SaveTemplate', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SaveTemplate",
+            "id" : this.__next_id(),
+            "params" : [template]
+        }));
+    }
+
+    /**
+    Remove template (returns true if template existed)
+    **/
+    async removeTemplate(name){
+        return (await this.__call('RemoveTemplate', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RemoveTemplate",
+            "id" : this.__next_id(),
+            "params" : [name]
+        }));
+    }
+
+    /**
+    Removed networks that could be restored
+    **/
+    async trash(){
+        return (await this.__call('Trash', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Trash",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Restore removed network from trash by item ID
+    **/
+    async restore(id){
+        return (await this.__call('Restore', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Restore",
+            "id" : this.__next_id(),
+            "params" : [id]
+        }));
+    }
+
+    /**
+    Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+    **/
+    async backup(){
+        return (await this.__call('Backup', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Backup",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+Running networks are started again after restore
+    **/
+    async restoreBackup(archive, options){
+        return (await this.__call('RestoreBackup', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RestoreBackup",
+            "id" : this.__next_id(),
+            "params" : [archive, options]
+        }));
+    }
+
     /**
     Start or re-start network
     **/
@@ -92,6 +229,18 @@ export class TincWeb {
         }));
     }
 
+    /**
+    Runtime status of network: process, uptime, restarts, interface and peers
+    **/
+    async status(network){
+        return (await this.__call('Status', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Status",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
     /**
     Peers brief list in network  (briefly, without config)
     **/
@@ -119,7 +268,8 @@ export class TincWeb {
     /**
     Import another tinc-web network configuration file.
 It means let nodes defined in config join to the network.
-Return created (or used) network with full configuration
+Return created (or used) network with full configuration.
+Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
     **/
     async import(sharing){
         return (await this.__call('Import', {
@@ -131,7 +281,23 @@ Return created (or used) network with full configuration
     }
 
     /**
-    Share network and generate configuration file.
+    Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+(code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+Encrypted document is decrypted by passphrase from options before checks.
+Return created (or used) network with full configuration and signer of document
+    **/
+    async importSigned(sharing, options){
+        return (await this.__call('ImportSigned', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ImportSigned",
+            "id" : this.__next_id(),
+            "params" : [sharing, options]
+        }));
+    }
+
+    /**
+    Share network and generate configuration file signed by owner key of network.
     **/
     async share(network){
         return (await this.__call('Share', {
@@ -142,6 +308,18 @@ Return created (or used) network with full configuration
         }));
     }
 
+    /**
+    Share network and generate signed configuration file encrypted by passphrase from options
+    **/
+    async shareEncrypted(network, options){
+        return (await this.__call('ShareEncrypted', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ShareEncrypted",
+            "id" : this.__next_id(),
+            "params" : [network, options]
+        }));
+    }
+
     /**
     Node definition in network (aka - self node)
     **/
@@ -156,7 +334,7 @@ Return created (or used) network with full configuration
 
     /**
     Upgrade node parameters.
-In some cases requires restart
+Running network reloads configuration or restarts (if port or device changed) automatically
     **/
     async upgrade(network, update){
         return (await this.__call('Upgrade', {
@@ -168,7 +346,7 @@ In some cases requires restart
     }
 
     /**
-    Generate Majordomo request for easy-sharing
+    Generate Majordomo request for easy-sharing (invitation without limits)
     **/
     async majordomo(network, lifetime){
         return (await this.__call('Majordomo', {
@@ -180,7 +358,92 @@ In some cases requires restart
     }
 
     /**
-    Join by Majordomo Link
+    Generate Majordomo link with limited number of uses and optional fixed node name
+    **/
+    async invite(network, options){
+        return (await this.__call('Invite', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invite",
+            "id" : this.__next_id(),
+            "params" : [network, options]
+        }));
+    }
+
+    /**
+    Invitations to network with redemptions
+    **/
+    async invitations(network){
+        return (await this.__call('Invitations', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invitations",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Invitation to network by ID
+    **/
+    async invitation(network, id){
+        return (await this.__call('Invitation', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invitation",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        }));
+    }
+
+    /**
+    Revoke invitation, link will not work anymore. Returns true if invitation was active
+    **/
+    async revokeInvitation(network, id){
+        return (await this.__call('RevokeInvitation', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RevokeInvitation",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        }));
+    }
+
+    /**
+    Requests to join network by invitations which require approval
+    **/
+    async joinRequests(network){
+        return (await this.__call('JoinRequests', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.JoinRequests",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Approve join request. Waiting node joins network on next poll
+    **/
+    async approveJoin(network, id){
+        return (await this.__call('ApproveJoin', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ApproveJoin",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        }));
+    }
+
+    /**
+    Reject join request
+    **/
+    async rejectJoin(network, id){
+        return (await this.__call('RejectJoin', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RejectJoin",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        }));
+    }
+
+    /**
+    Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+of inviting node (or rejected, or invitation expired)
     **/
     async join(url, start){
         return (await this.__call('Join', {
@@ -191,6 +454,103 @@ In some cases requires restart
         }));
     }
 
+    /**
+    Start network automatically with tinc-web-boot
+    **/
+    async enableAutostart(network){
+        return (await this.__call('EnableAutostart', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.EnableAutostart",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Do not start network automatically with tinc-web-boot
+    **/
+    async disableAutostart(network){
+        return (await this.__call('DisableAutostart', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.DisableAutostart",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Names of networks that will be started automatically
+    **/
+    async autostartNetworks(){
+        return (await this.__call('AutostartNetworks', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.AutostartNetworks",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Effective policy of tincd restarts after unexpected exit
+    **/
+    async restartPolicy(network){
+        return (await this.__call('RestartPolicy', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RestartPolicy",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Set custom restart policy for the network. Empty mode resets policy to default.
+Applied immediately for running network
+    **/
+    async setRestartPolicy(network, policy){
+        return (await this.__call('SetRestartPolicy', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SetRestartPolicy",
+            "id" : this.__next_id(),
+            "params" : [network, policy]
+        }));
+    }
+
+    /**
+    Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
+    **/
+    async leases(network){
+        return (await this.__call('Leases', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Leases",
+            "id" : this.__next_id(),
+            "params" : [network]
+        }));
+    }
+
+    /**
+    Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
+    **/
+    async setLease(network, node, ip){
+        return (await this.__call('SetLease', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SetLease",
+            "id" : this.__next_id(),
+            "params" : [network, node, ip]
+        }));
+    }
+
+    /**
+    Remove address lease of node (returns true if lease existed)
+    **/
+    async removeLease(network, node){
+        return (await this.__call('RemoveLease', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RemoveLease",
+            "id" : this.__next_id(),
+            "params" : [network, node]
+        }));
+    }
+
 
 
     __next_id() {
@@ -21,7 +21,10 @@ export class TincWebMajordomo {
 
 
     /**
-    Join public network if code matched. Will generate error if node subnet not matched
+    Join public network if code matched. Node receives free address inside network subnet
+(returned sharing contains node with assigned address).
+Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
     **/
     async join(network, self){
         return (await this.__call('Join', {
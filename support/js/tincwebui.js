@@ -32,6 +32,67 @@ export class TincWebUI {
         }));
     }
 
+    /**
+    Issue and sign token for role (admin, operator or viewer) with optional label
+    **/
+    async issueToken(role, label, validDays){
+        return (await this.__call('IssueToken', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.IssueToken",
+            "id" : this.__next_id(),
+            "params" : [role, label, validDays]
+        }));
+    }
+
+    /**
+    Issued and not expired tokens including majordomo links
+    **/
+    async listTokens(){
+        return (await this.__call('ListTokens', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.ListTokens",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Revoke token by ID. Returns true if token existed
+    **/
+    async revokeToken(id){
+        return (await this.__call('RevokeToken', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.RevokeToken",
+            "id" : this.__next_id(),
+            "params" : [id]
+        }));
+    }
+
+    /**
+    Active signing keys, first one is current
+    **/
+    async signingKeys(){
+        return (await this.__call('SigningKeys', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.SigningKeys",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
+    /**
+    Generate new signing key. Previous keys are valid for verification during grace period.
+Returns active signing keys
+    **/
+    async rotateKey(grace){
+        return (await this.__call('RotateKey', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.RotateKey",
+            "id" : this.__next_id(),
+            "params" : [grace]
+        }));
+    }
+
     /**
     Make desktop notification if system supports it
     **/
@@ -68,6 +129,18 @@ export class TincWebUI {
         }));
     }
 
+    /**
+    Tincd binaries (default and per-network) with detected versions
+    **/
+    async binaries(){
+        return (await this.__call('Binaries', {
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.Binaries",
+            "id" : this.__next_id(),
+            "params" : []
+        }));
+    }
+
 
 
     __next_id() {
@@ -4,6 +4,7 @@ from dataclasses import dataclass
 
 from enum import Enum
 from typing import Any, List, Optional
+from base64 import decodebytes, encodebytes
 
 
 class Duration(Enum):
@@ -21,17 +22,45 @@ class Duration(Enum):
         return Duration(payload)
 
 
+class JoinStatus(Enum):
+    JOIN_PENDING = "pending"
+    JOIN_APPROVED = "approved"
+    JOIN_REJECTED = "rejected"
+
+    def to_json(self) -> str:
+        return self.value
+
+    @staticmethod
+    def from_json(payload: str) -> 'JoinStatus':
+        return JoinStatus(payload)
+
+
+class RestartMode(Enum):
+    RESTART_NEVER = "never"
+    RESTART_ON_FAILURE = "on-failure"
+    RESTART_ALWAYS = "always"
+
+    def to_json(self) -> str:
+        return self.value
+
+    @staticmethod
+    def from_json(payload: str) -> 'RestartMode':
+        return RestartMode(payload)
+
+
 
 @dataclass
 class Network:
     name: 'str'
     running: 'bool'
+    autostart: 'bool'
     config: 'Optional[Config]'
 
     def to_json(self) -> dict:
         return {
             "name": self.name,
             "running": self.running,
+            "autostart": self.autostart,
             "config": self.config.to_json(),
         }
 
@@ -40,6 +69,7 @@ class Network:
         return Network(
                 name=payload['name'],
                 running=payload['running'],
+                autostart=payload['autostart'],
                 config=Config.from_json(payload['config']),
         )
 
@@ -84,6 +114,246 @@ class Config:
         )
 
 
+@dataclass
+class Template:
+    name: 'str'
+    subnet: 'Optional[str]'
+    device_type: 'Optional[str]'
+    mode: 'Optional[str]'
+    min_port: 'Optional[int]'
+    max_port: 'Optional[int]'
+    address: 'Optional[List[Address]]'
+    compression: 'Optional[int]'
+    scripts: 'Optional[Any]'
+
+    def to_json(self) -> dict:
+        return {
+            "name": self.name,
+            "subnet": self.subnet,
+            "device_type": self.device_type,
+            "mode": self.mode,
+            "min_port": self.min_port,
+            "max_port": self.max_port,
+            "address": [x.to_json() for x in self.address],
+            "compression": self.compression,
+            "scripts": self.scripts,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Template':
+        return Template(
+                name=payload['name'],
+                subnet=payload['subnet'],
+                device_type=payload['device_type'],
+                mode=payload['mode'],
+                min_port=payload['min_port'],
+                max_port=payload['max_port'],
+                address=[Address.from_json(x) for x in (payload['address'] or [])],
+                compression=payload['compression'],
+                scripts=payload['scripts'],
+        )
+
+
+@dataclass
+class Address:
+    host: 'str'
+    port: 'Optional[int]'
+
+    def to_json(self) -> dict:
+        return {
+            "host": self.host,
+            "port": self.port,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Address':
+        return Address(
+                host=payload['host'],
+                port=payload['port'],
+        )
+
+
+@dataclass
+class TrashItem:
+    id: 'str'
+    network: 'str'
+    removed: 'Any'
+    expires: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "id": self.id,
+            "network": self.network,
+            "removed": self.removed,
+            "expires": self.expires,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'TrashItem':
+        return TrashItem(
+                id=payload['id'],
+                network=payload['network'],
+                removed=payload['removed'],
+                expires=payload['expires'],
+        )
+
+
+@dataclass
+class Backup:
+    manifest: 'BackupManifest'
+    archive: 'bytes'
+
+    def to_json(self) -> dict:
+        return {
+            "manifest": self.manifest.to_json(),
+            "archive": encodebytes(self.archive),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Backup':
+        return Backup(
+                manifest=BackupManifest.from_json(payload['manifest']),
+                archive=decodebytes((payload['archive'] or '').encode()),
+        )
+
+
+@dataclass
+class BackupManifest:
+    version: 'int'
+    created: 'Any'
+    networks: 'List[BackupNetwork]'
+
+    def to_json(self) -> dict:
+        return {
+            "version": self.version,
+            "created": self.created,
+            "networks": [x.to_json() for x in self.networks],
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'BackupManifest':
+        return BackupManifest(
+                version=payload['version'],
+                created=payload['created'],
+                networks=[BackupNetwork.from_json(x) for x in (payload['networks'] or [])],
+        )
+
+
+@dataclass
+class BackupNetwork:
+    name: 'str'
+    node: 'str'
+    subnet: 'str'
+    autostart: 'bool'
+    files: 'List[str]'
+
+    def to_json(self) -> dict:
+        return {
+            "name": self.name,
+            "node": self.node,
+            "subnet": self.subnet,
+            "autostart": self.autostart,
+            "files": self.files,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'BackupNetwork':
+        return BackupNetwork(
+                name=payload['name'],
+                node=payload['node'],
+                subnet=payload['subnet'],
+                autostart=payload['autostart'],
+                files=payload['files'] or [],
+        )
+
+
+@dataclass
+class RestoreOptions:
+    networks: 'Optional[List[str]]'
+    skip_existing: 'Optional[bool]'
+
+    def to_json(self) -> dict:
+        return {
+            "networks": self.networks,
+            "skip_existing": self.skip_existing,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'RestoreOptions':
+        return RestoreOptions(
+                networks=payload['networks'] or [],
+                skip_existing=payload['skip_existing'],
+        )
+
+
+@dataclass
+class RestoreResult:
+    restored: 'List[str]'
+    skipped: 'Optional[List[str]]'
+
+    def to_json(self) -> dict:
+        return {
+            "restored": self.restored,
+            "skipped": self.skipped,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'RestoreResult':
+        return RestoreResult(
+                restored=payload['restored'] or [],
+                skipped=payload['skipped'] or [],
+        )
+
+
+@dataclass
+class Status:
+    network: 'str'
+    running: 'bool'
+    pid: 'Optional[int]'
+    started: 'Optional[Any]'
+    uptime: 'Optional[Duration]'
+    restarts: 'int'
+    last_error: 'Optional[str]'
+    interface: 'Optional[str]'
+    addresses: 'Optional[List[str]]'
+    port: 'int'
+    known_peers: 'int'
+    online_peers: 'int'
+
+    def to_json(self) -> dict:
+        return {
+            "network": self.network,
+            "running": self.running,
+            "pid": self.pid,
+            "started": self.started,
+            "uptime": self.uptime.to_json(),
+            "restarts": self.restarts,
+            "last_error": self.last_error,
+            "interface": self.interface,
+            "addresses": self.addresses,
+            "port": self.port,
+            "known_peers": self.known_peers,
+            "online_peers": self.online_peers,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Status':
+        return Status(
+                network=payload['network'],
+                running=payload['running'],
+                pid=payload['pid'],
+                started=payload['started'],
+                uptime=Duration.from_json(payload['uptime']),
+                restarts=payload['restarts'],
+                last_error=payload['last_error'],
+                interface=payload['interface'],
+                addresses=payload['addresses'] or [],
+                port=payload['port'],
+                known_peers=payload['known_peers'],
+                online_peers=payload['online_peers'],
+        )
+
+
 @dataclass
 class PeerInfo:
     name: 'str'
@@ -141,43 +411,171 @@ class Node:
 
 
 @dataclass
-class Address:
-    host: 'str'
-    port: 'Optional[int]'
+class Sharing:
+    name: 'str'
+    subnet: 'str'
+    nodes: 'Optional[List[Node]]'
+    signature: 'Optional[Signature]'
+    encrypted: 'Optional[Encrypted]'
 
     def to_json(self) -> dict:
         return {
-            "host": self.host,
-            "port": self.port,
+            "name": self.name,
+            "subnet": self.subnet,
+            "node": [x.to_json() for x in self.nodes],
+            "signature": self.signature.to_json(),
+            "encrypted": self.encrypted.to_json(),
         }
 
     @staticmethod
-    def from_json(payload: dict) -> 'Address':
-        return Address(
-                host=payload['host'],
-                port=payload['port'],
+    def from_json(payload: dict) -> 'Sharing':
+        return Sharing(
+                name=payload['name'],
+                subnet=payload['subnet'],
+                nodes=[Node.from_json(x) for x in (payload['node'] or [])],
+                signature=Signature.from_json(payload['signature']),
+                encrypted=Encrypted.from_json(payload['encrypted']),
         )
 
 
 @dataclass
-class Sharing:
+class Signature:
+    signer: 'str'
+    key: 'bytes'
+    value: 'bytes'
+
+    def to_json(self) -> dict:
+        return {
+            "signer": self.signer,
+            "key": encodebytes(self.key),
+            "value": encodebytes(self.value),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Signature':
+        return Signature(
+                signer=payload['signer'],
+                key=decodebytes((payload['key'] or '').encode()),
+                value=decodebytes((payload['value'] or '').encode()),
+        )
+
+
+@dataclass
+class Encrypted:
+    format: 'str'
+    kdf: 'KDFParams'
+    cipher: 'str'
+    nonce: 'bytes'
+    data: 'bytes'
+
+    def to_json(self) -> dict:
+        return {
+            "format": self.format,
+            "kdf": self.kdf.to_json(),
+            "cipher": self.cipher,
+            "nonce": encodebytes(self.nonce),
+            "data": encodebytes(self.data),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Encrypted':
+        return Encrypted(
+                format=payload['format'],
+                kdf=KDFParams.from_json(payload['kdf']),
+                cipher=payload['cipher'],
+                nonce=decodebytes((payload['nonce'] or '').encode()),
+                data=decodebytes((payload['data'] or '').encode()),
+        )
+
+
+@dataclass
+class KDFParams:
     name: 'str'
-    subnet: 'str'
-    nodes: 'Optional[List[Node]]'
+    salt: 'bytes'
+    n: 'int'
+    r: 'int'
+    p: 'int'
 
     def to_json(self) -> dict:
         return {
             "name": self.name,
-            "subnet": self.subnet,
-            "node": [x.to_json() for x in self.nodes],
+            "salt": encodebytes(self.salt),
+            "n": self.n,
+            "r": self.r,
+            "p": self.p,
         }
 
     @staticmethod
-    def from_json(payload: dict) -> 'Sharing':
-        return Sharing(
+    def from_json(payload: dict) -> 'KDFParams':
+        return KDFParams(
                 name=payload['name'],
-                subnet=payload['subnet'],
-                nodes=[Node.from_json(x) for x in (payload['node'] or [])],
+                salt=decodebytes((payload['salt'] or '').encode()),
+                n=payload['n'],
+                r=payload['r'],
+                p=payload['p'],
+        )
+
+
+@dataclass
+class ImportOptions:
+    name: 'Optional[str]'
+    signer: 'Optional[str]'
+    force: 'Optional[bool]'
+    passphrase: 'Optional[str]'
+
+    def to_json(self) -> dict:
+        return {
+            "name": self.name,
+            "signer": self.signer,
+            "force": self.force,
+            "passphrase": self.passphrase,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'ImportOptions':
+        return ImportOptions(
+                name=payload['name'],
+                signer=payload['signer'],
+                force=payload['force'],
+                passphrase=payload['passphrase'],
+        )
+
+
+@dataclass
+class Imported:
+    network: 'Network'
+    signer: 'Optional[str]'
+    trusted: 'bool'
+
+    def to_json(self) -> dict:
+        return {
+            "network": self.network.to_json(),
+            "signer": self.signer,
+            "trusted": self.trusted,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Imported':
+        return Imported(
+                network=Network.from_json(payload['network']),
+                signer=payload['signer'],
+                trusted=payload['trusted'],
+        )
+
+
+@dataclass
+class ShareOptions:
+    passphrase: 'str'
+
+    def to_json(self) -> dict:
+        return {
+            "passphrase": self.passphrase,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'ShareOptions':
+        return ShareOptions(
+                passphrase=payload['passphrase'],
         )
 
 
@@ -203,263 +601,948 @@ class Upgrade:
         )
 
 
-class TincWebError(RuntimeError):
-    def __init__(self, method: str, code: int, message: str, data: Any):
-        super().__init__('{}: {}: {} - {}'.format(method, code, message, data))
-        self.code = code
-        self.message = message
-        self.data = data
+@dataclass
+class UpgradeResult:
+    node: 'Node'
+    restart_required: 'bool'
+    reloaded: 'bool'
+
+    def to_json(self) -> dict:
+        return {
+            "node": self.node.to_json(),
+            "restart_required": self.restart_required,
+            "reloaded": self.reloaded,
+        }
 
     @staticmethod
-    def from_json(method: str, payload: dict) -> 'TincWebError':
-        return TincWebError(
-            method=method,
-            code=payload['code'],
-            message=payload['message'],
-            data=payload.get('data')
+    def from_json(payload: dict) -> 'UpgradeResult':
+        return UpgradeResult(
+                node=Node.from_json(payload['node']),
+                restart_required=payload['restart_required'],
+                reloaded=payload['reloaded'],
         )
 
 
-class TincWebClient:
-    """
-    Public Tinc-Web API (json-rpc 2.0)
-    """
+@dataclass
+class Invite:
+    link: 'str'
+    invitation: 'Invitation'
+
+    def to_json(self) -> dict:
+        return {
+            "link": self.link,
+            "invitation": self.invitation.to_json(),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Invite':
+        return Invite(
+                link=payload['link'],
+                invitation=Invitation.from_json(payload['invitation']),
+        )
+
+
+@dataclass
+class Invitation:
+    id: 'str'
+    node_name: 'Optional[str]'
+    max_uses: 'Optional[int]'
+    approval: 'Optional[bool]'
+    created: 'Any'
+    expires: 'Any'
+    revoked: 'Optional[bool]'
+    redemptions: 'Optional[List[Redemption]]'
+
+    def to_json(self) -> dict:
+        return {
+            "id": self.id,
+            "node_name": self.node_name,
+            "max_uses": self.max_uses,
+            "approval": self.approval,
+            "created": self.created,
+            "expires": self.expires,
+            "revoked": self.revoked,
+            "redemptions": [x.to_json() for x in self.redemptions],
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Invitation':
+        return Invitation(
+                id=payload['id'],
+                node_name=payload['node_name'],
+                max_uses=payload['max_uses'],
+                approval=payload['approval'],
+                created=payload['created'],
+                expires=payload['expires'],
+                revoked=payload['revoked'],
+                redemptions=[Redemption.from_json(x) for x in (payload['redemptions'] or [])],
+        )
+
+
+@dataclass
+class Redemption:
+    node: 'str'
+    address: 'Optional[str]'
+    time: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "node": self.node,
+            "address": self.address,
+            "time": self.time,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Redemption':
+        return Redemption(
+                node=payload['node'],
+                address=payload['address'],
+                time=payload['time'],
+        )
+
+
+@dataclass
+class InviteOptions:
+    lifetime: 'Duration'
+    max_uses: 'Optional[int]'
+    node_name: 'Optional[str]'
+    approval: 'Optional[bool]'
+
+    def to_json(self) -> dict:
+        return {
+            "lifetime": self.lifetime.to_json(),
+            "max_uses": self.max_uses,
+            "node_name": self.node_name,
+            "approval": self.approval,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'InviteOptions':
+        return InviteOptions(
+                lifetime=Duration.from_json(payload['lifetime']),
+                max_uses=payload['max_uses'],
+                node_name=payload['node_name'],
+                approval=payload['approval'],
+        )
+
+
+@dataclass
+class JoinRequest:
+    id: 'str'
+    invitation: 'str'
+    node: 'Node'
+    address: 'Optional[str]'
+    status: 'JoinStatus'
+    created: 'Any'
+    expires: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "id": self.id,
+            "invitation": self.invitation,
+            "node": self.node.to_json(),
+            "address": self.address,
+            "status": self.status.to_json(),
+            "created": self.created,
+            "expires": self.expires,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'JoinRequest':
+        return JoinRequest(
+                id=payload['id'],
+                invitation=payload['invitation'],
+                node=Node.from_json(payload['node']),
+                address=payload['address'],
+                status=JoinStatus.from_json(payload['status']),
+                created=payload['created'],
+                expires=payload['expires'],
+        )
+
+
+@dataclass
+class RestartPolicy:
+    mode: 'RestartMode'
+    min_backoff: 'Optional[Duration]'
+    max_backoff: 'Optional[Duration]'
+    max_restarts: 'Optional[int]'
+    window: 'Optional[Duration]'
+
+    def to_json(self) -> dict:
+        return {
+            "mode": self.mode.to_json(),
+            "min_backoff": self.min_backoff.to_json(),
+            "max_backoff": self.max_backoff.to_json(),
+            "max_restarts": self.max_restarts,
+            "window": self.window.to_json(),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'RestartPolicy':
+        return RestartPolicy(
+                mode=RestartMode.from_json(payload['mode']),
+                min_backoff=Duration.from_json(payload['min_backoff']),
+                max_backoff=Duration.from_json(payload['max_backoff']),
+                max_restarts=payload['max_restarts'],
+                window=Duration.from_json(payload['window']),
+        )
+
+
+@dataclass
+class Lease:
+    node: 'str'
+    ip: 'str'
+    updated: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "node": self.node,
+            "ip": self.ip,
+            "updated": self.updated,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Lease':
+        return Lease(
+                node=payload['node'],
+                ip=payload['ip'],
+                updated=payload['updated'],
+        )
+
+
+class TincWebError(RuntimeError):
+    def __init__(self, method: str, code: int, message: str, data: Any):
+        super().__init__('{}: {}: {} - {}'.format(method, code, message, data))
+        self.code = code
+        self.message = message
+        self.data = data
+
+    @staticmethod
+    def from_json(method: str, payload: dict) -> 'TincWebError':
+        return TincWebError(
+            method=method,
+            code=payload['code'],
+            message=payload['message'],
+            data=payload.get('data')
+        )
+
+
+class TincWebClient:
+    """
+    Public Tinc-Web API (json-rpc 2.0)
+    """
+
+    def __init__(self, base_url: str = 'http://127.0.0.1:8686/api/', session: Optional[client.ClientSession] = None):
+        self.__url = base_url
+        self.__id = 1
+        self.__request = session.request if session is not None else client.request
+
+    def __next_id(self):
+        self.__id += 1
+        return self.__id
+
+    async def networks(self) -> List[Network]:
+        """
+        List of available networks (briefly, without config)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Networks",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('networks', payload['error'])
+        return [Network.from_json(x) for x in (payload['result'] or [])]
+
+    async def network(self, name: str) -> Network:
+        """
+        Detailed network info
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Network",
+            "id": self.__next_id(),
+            "params": [name, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('network', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def create(self, name: str, subnet: str) -> Network:
+        """
+        Create new network if not exists. Empty subnet means automatically allocated free subnet.
+Subnet should not overlap with other networks and host routes
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Create",
+            "id": self.__next_id(),
+            "params": [name, subnet, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('create', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def create_from_template(self, name: str, subnet: str, template: str) -> Network:
+        """
+        Create new network if not exists with settings from template (or default settings if template name is empty).
+Empty subnet means subnet from template or automatically allocated free subnet
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.CreateFromTemplate",
+            "id": self.__next_id(),
+            "params": [name, subnet, template, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('create_from_template', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def rename(self, network: str, new_name: str) -> Network:
+        """
+        Rename network. Running network is restarted, autostart and interface name are kept
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Rename",
+            "id": self.__next_id(),
+            "params": [network, new_name, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('rename', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def clone(self, source: str, network: str, subnet: str) -> Network:
+        """
+        Create new network with settings of existent network (keys and self node are generated).
+Empty subnet means automatically allocated free subnet
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Clone",
+            "id": self.__next_id(),
+            "params": [source, network, subnet, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('clone', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def remove(self, network: str) -> bool:
+        """
+        Remove network (returns true if network existed).
+Network is stopped and moved to trash, from where it could be restored during retention period
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Remove",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('remove', payload['error'])
+        return payload['result']
+
+    async def templates(self) -> List[Template]:
+        """
+        Templates for new networks
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Templates",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('templates', payload['error'])
+        return [Template.from_json(x) for x in (payload['result'] or [])]
+
+    async def template(self, name: str) -> Template:
+        """
+        Template by name
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Template",
+            "id": self.__next_id(),
+            "params": [name, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('template', payload['error'])
+        return Template.from_json(payload['result'])
+
+    async def save_template(self, template: Template) -> Template:
+        """
+        Create or replace template
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.SaveTemplate",
+            "id": self.__next_id(),
+            "params": [template.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('save_template', payload['error'])
+        return Template.from_json(payload['result'])
+
+    async def remove_template(self, name: str) -> bool:
+        """
+        Remove template (returns true if template existed)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.RemoveTemplate",
+            "id": self.__next_id(),
+            "params": [name, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('remove_template', payload['error'])
+        return payload['result']
+
+    async def trash(self) -> List[TrashItem]:
+        """
+        Removed networks that could be restored
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Trash",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('trash', payload['error'])
+        return [TrashItem.from_json(x) for x in (payload['result'] or [])]
+
+    async def restore(self, id: str) -> Network:
+        """
+        Restore removed network from trash by item ID
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Restore",
+            "id": self.__next_id(),
+            "params": [id, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('restore', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def backup(self) -> Backup:
+        """
+        Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Backup",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('backup', payload['error'])
+        return Backup.from_json(payload['result'])
+
+    async def restore_backup(self, archive: bytes, options: RestoreOptions) -> RestoreResult:
+        """
+        Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+Running networks are started again after restore
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.RestoreBackup",
+            "id": self.__next_id(),
+            "params": [encodebytes(archive), options.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('restore_backup', payload['error'])
+        return RestoreResult.from_json(payload['result'])
+
+    async def start(self, network: str) -> Network:
+        """
+        Start or re-start network
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Start",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('start', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def stop(self, network: str) -> Network:
+        """
+        Stop network
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Stop",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('stop', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def status(self, network: str) -> Status:
+        """
+        Runtime status of network: process, uptime, restarts, interface and peers
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Status",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('status', payload['error'])
+        return Status.from_json(payload['result'])
+
+    async def peers(self, network: str) -> List[PeerInfo]:
+        """
+        Peers brief list in network  (briefly, without config)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Peers",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('peers', payload['error'])
+        return [PeerInfo.from_json(x) for x in (payload['result'] or [])]
+
+    async def peer(self, network: str, name: str) -> PeerInfo:
+        """
+        Peer detailed info by in the network
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Peer",
+            "id": self.__next_id(),
+            "params": [network, name, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('peer', payload['error'])
+        return PeerInfo.from_json(payload['result'])
+
+    async def import(self, sharing: Sharing) -> Network:
+        """
+        Import another tinc-web network configuration file.
+It means let nodes defined in config join to the network.
+Return created (or used) network with full configuration.
+Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Import",
+            "id": self.__next_id(),
+            "params": [sharing.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('import', payload['error'])
+        return Network.from_json(payload['result'])
+
+    async def import_signed(self, sharing: Sharing, options: ImportOptions) -> Imported:
+        """
+        Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+(code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+Encrypted document is decrypted by passphrase from options before checks.
+Return created (or used) network with full configuration and signer of document
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.ImportSigned",
+            "id": self.__next_id(),
+            "params": [sharing.to_json(), options.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('import_signed', payload['error'])
+        return Imported.from_json(payload['result'])
+
+    async def share(self, network: str) -> Sharing:
+        """
+        Share network and generate configuration file signed by owner key of network.
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Share",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('share', payload['error'])
+        return Sharing.from_json(payload['result'])
+
+    async def share_encrypted(self, network: str, options: ShareOptions) -> Sharing:
+        """
+        Share network and generate signed configuration file encrypted by passphrase from options
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.ShareEncrypted",
+            "id": self.__next_id(),
+            "params": [network, options.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('share_encrypted', payload['error'])
+        return Sharing.from_json(payload['result'])
+
+    async def node(self, network: str) -> Node:
+        """
+        Node definition in network (aka - self node)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Node",
+            "id": self.__next_id(),
+            "params": [network, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('node', payload['error'])
+        return Node.from_json(payload['result'])
 
-    def __init__(self, base_url: str = 'http://127.0.0.1:8686/api/', session: Optional[client.ClientSession] = None):
-        self.__url = base_url
-        self.__id = 1
-        self.__request = session.request if session is not None else client.request
+    async def upgrade(self, network: str, update: Upgrade) -> UpgradeResult:
+        """
+        Upgrade node parameters.
+Running network reloads configuration or restarts (if port or device changed) automatically
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Upgrade",
+            "id": self.__next_id(),
+            "params": [network, update.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('upgrade', payload['error'])
+        return UpgradeResult.from_json(payload['result'])
 
-    def __next_id(self):
-        self.__id += 1
-        return self.__id
+    async def majordomo(self, network: str, lifetime: Duration) -> str:
+        """
+        Generate Majordomo request for easy-sharing (invitation without limits)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.Majordomo",
+            "id": self.__next_id(),
+            "params": [network, lifetime.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('majordomo', payload['error'])
+        return payload['result']
 
-    async def networks(self) -> List[Network]:
+    async def invite(self, network: str, options: InviteOptions) -> Invite:
         """
-        List of available networks (briefly, without config)
+        Generate Majordomo link with limited number of uses and optional fixed node name
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Networks",
+            "method": "TincWeb.Invite",
             "id": self.__next_id(),
-            "params": []
+            "params": [network, options.to_json(), ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('networks', payload['error'])
-        return [Network.from_json(x) for x in (payload['result'] or [])]
+            raise TincWebError.from_json('invite', payload['error'])
+        return Invite.from_json(payload['result'])
 
-    async def network(self, name: str) -> Network:
+    async def invitations(self, network: str) -> List[Invitation]:
         """
-        Detailed network info
+        Invitations to network with redemptions
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Network",
+            "method": "TincWeb.Invitations",
             "id": self.__next_id(),
-            "params": [name, ]
+            "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('network', payload['error'])
-        return Network.from_json(payload['result'])
+            raise TincWebError.from_json('invitations', payload['error'])
+        return [Invitation.from_json(x) for x in (payload['result'] or [])]
 
-    async def create(self, name: str, subnet: str) -> Network:
+    async def invitation(self, network: str, id: str) -> Invitation:
         """
-        Create new network if not exists
+        Invitation to network by ID
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Create",
+            "method": "TincWeb.Invitation",
             "id": self.__next_id(),
-            "params": [name, subnet, ]
+            "params": [network, id, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('create', payload['error'])
-        return Network.from_json(payload['result'])
+            raise TincWebError.from_json('invitation', payload['error'])
+        return Invitation.from_json(payload['result'])
 
-    async def remove(self, network: str) -> bool:
+    async def revoke_invitation(self, network: str, id: str) -> bool:
         """
-        Remove network (returns true if network existed)
+        Revoke invitation, link will not work anymore. Returns true if invitation was active
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Remove",
+            "method": "TincWeb.RevokeInvitation",
             "id": self.__next_id(),
-            "params": [network, ]
+            "params": [network, id, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('remove', payload['error'])
+            raise TincWebError.from_json('revoke_invitation', payload['error'])
         return payload['result']
 
-    async def start(self, network: str) -> Network:
+    async def join_requests(self, network: str) -> List[JoinRequest]:
         """
-        Start or re-start network
+        Requests to join network by invitations which require approval
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Start",
+            "method": "TincWeb.JoinRequests",
             "id": self.__next_id(),
             "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('start', payload['error'])
-        return Network.from_json(payload['result'])
+            raise TincWebError.from_json('join_requests', payload['error'])
+        return [JoinRequest.from_json(x) for x in (payload['result'] or [])]
 
-    async def stop(self, network: str) -> Network:
+    async def approve_join(self, network: str, id: str) -> JoinRequest:
         """
-        Stop network
+        Approve join request. Waiting node joins network on next poll
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Stop",
+            "method": "TincWeb.ApproveJoin",
             "id": self.__next_id(),
-            "params": [network, ]
+            "params": [network, id, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('stop', payload['error'])
-        return Network.from_json(payload['result'])
+            raise TincWebError.from_json('approve_join', payload['error'])
+        return JoinRequest.from_json(payload['result'])
 
-    async def peers(self, network: str) -> List[PeerInfo]:
+    async def reject_join(self, network: str, id: str) -> JoinRequest:
         """
-        Peers brief list in network  (briefly, without config)
+        Reject join request
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Peers",
+            "method": "TincWeb.RejectJoin",
             "id": self.__next_id(),
-            "params": [network, ]
+            "params": [network, id, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('peers', payload['error'])
-        return [PeerInfo.from_json(x) for x in (payload['result'] or [])]
+            raise TincWebError.from_json('reject_join', payload['error'])
+        return JoinRequest.from_json(payload['result'])
 
-    async def peer(self, network: str, name: str) -> PeerInfo:
+    async def join(self, url: str, start: bool) -> Network:
         """
-        Peer detailed info by in the network
+        Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+of inviting node (or rejected, or invitation expired)
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Peer",
+            "method": "TincWeb.Join",
             "id": self.__next_id(),
-            "params": [network, name, ]
+            "params": [url, start, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('peer', payload['error'])
-        return PeerInfo.from_json(payload['result'])
+            raise TincWebError.from_json('join', payload['error'])
+        return Network.from_json(payload['result'])
 
-    async def import(self, sharing: Sharing) -> Network:
+    async def enable_autostart(self, network: str) -> Network:
         """
-        Import another tinc-web network configuration file.
-It means let nodes defined in config join to the network.
-Return created (or used) network with full configuration
+        Start network automatically with tinc-web-boot
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Import",
+            "method": "TincWeb.EnableAutostart",
             "id": self.__next_id(),
-            "params": [sharing.to_json(), ]
+            "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('import', payload['error'])
+            raise TincWebError.from_json('enable_autostart', payload['error'])
         return Network.from_json(payload['result'])
 
-    async def share(self, network: str) -> Sharing:
+    async def disable_autostart(self, network: str) -> Network:
         """
-        Share network and generate configuration file.
+        Do not start network automatically with tinc-web-boot
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Share",
+            "method": "TincWeb.DisableAutostart",
             "id": self.__next_id(),
             "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('share', payload['error'])
-        return Sharing.from_json(payload['result'])
+            raise TincWebError.from_json('disable_autostart', payload['error'])
+        return Network.from_json(payload['result'])
 
-    async def node(self, network: str) -> Node:
+    async def autostart_networks(self) -> List[str]:
         """
-        Node definition in network (aka - self node)
+        Names of networks that will be started automatically
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Node",
+            "method": "TincWeb.AutostartNetworks",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('autostart_networks', payload['error'])
+        return payload['result'] or []
+
+    async def restart_policy(self, network: str) -> RestartPolicy:
+        """
+        Effective policy of tincd restarts after unexpected exit
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.RestartPolicy",
             "id": self.__next_id(),
             "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('node', payload['error'])
-        return Node.from_json(payload['result'])
+            raise TincWebError.from_json('restart_policy', payload['error'])
+        return RestartPolicy.from_json(payload['result'])
 
-    async def upgrade(self, network: str, update: Upgrade) -> Node:
+    async def set_restart_policy(self, network: str, policy: RestartPolicy) -> RestartPolicy:
         """
-        Upgrade node parameters.
-In some cases requires restart
+        Set custom restart policy for the network. Empty mode resets policy to default.
+Applied immediately for running network
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Upgrade",
+            "method": "TincWeb.SetRestartPolicy",
             "id": self.__next_id(),
-            "params": [network, update.to_json(), ]
+            "params": [network, policy.to_json(), ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('upgrade', payload['error'])
-        return Node.from_json(payload['result'])
+            raise TincWebError.from_json('set_restart_policy', payload['error'])
+        return RestartPolicy.from_json(payload['result'])
 
-    async def majordomo(self, network: str, lifetime: Duration) -> str:
+    async def leases(self, network: str) -> List[Lease]:
         """
-        Generate Majordomo request for easy-sharing
+        Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Majordomo",
+            "method": "TincWeb.Leases",
             "id": self.__next_id(),
-            "params": [network, lifetime.to_json(), ]
+            "params": [network, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('majordomo', payload['error'])
-        return payload['result']
+            raise TincWebError.from_json('leases', payload['error'])
+        return [Lease.from_json(x) for x in (payload['result'] or [])]
 
-    async def join(self, url: str, start: bool) -> Network:
+    async def set_lease(self, network: str, node: str, ip: str) -> Lease:
         """
-        Join by Majordomo Link
+        Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
-            "method": "TincWeb.Join",
+            "method": "TincWeb.SetLease",
             "id": self.__next_id(),
-            "params": [url, start, ]
+            "params": [network, node, ip, ]
         })
         assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
         payload = await response.json()
         if 'error' in payload:
-            raise TincWebError.from_json('join', payload['error'])
-        return Network.from_json(payload['result'])
+            raise TincWebError.from_json('set_lease', payload['error'])
+        return Lease.from_json(payload['result'])
+
+    async def remove_lease(self, network: str, node: str) -> bool:
+        """
+        Remove address lease of node (returns true if lease existed)
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWeb.RemoveLease",
+            "id": self.__next_id(),
+            "params": [network, node, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebError.from_json('remove_lease', payload['error'])
+        return payload['result']
 
     async def _invoke(self, request):
         return await self.__request('POST', self.__url, json=request)
@@ -499,20 +1582,113 @@ class TincWebBatch:
 
     def create(self, name: str, subnet: str):
         """
-        Create new network if not exists
+        Create new network if not exists. Empty subnet means automatically allocated free subnet.
+Subnet should not overlap with other networks and host routes
         """
         params = [name, subnet, ]
         method = "TincWeb.Create"
         self.__add_request(method, params, lambda payload: Network.from_json(payload))
 
+    def create_from_template(self, name: str, subnet: str, template: str):
+        """
+        Create new network if not exists with settings from template (or default settings if template name is empty).
+Empty subnet means subnet from template or automatically allocated free subnet
+        """
+        params = [name, subnet, template, ]
+        method = "TincWeb.CreateFromTemplate"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
+    def rename(self, network: str, new_name: str):
+        """
+        Rename network. Running network is restarted, autostart and interface name are kept
+        """
+        params = [network, new_name, ]
+        method = "TincWeb.Rename"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
+    def clone(self, source: str, network: str, subnet: str):
+        """
+        Create new network with settings of existent network (keys and self node are generated).
+Empty subnet means automatically allocated free subnet
+        """
+        params = [source, network, subnet, ]
+        method = "TincWeb.Clone"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
     def remove(self, network: str):
         """
-        Remove network (returns true if network existed)
+        Remove network (returns true if network existed).
+Network is stopped and moved to trash, from where it could be restored during retention period
         """
         params = [network, ]
         method = "TincWeb.Remove"
         self.__add_request(method, params, lambda payload: payload)
 
+    def templates(self):
+        """
+        Templates for new networks
+        """
+        params = []
+        method = "TincWeb.Templates"
+        self.__add_request(method, params, lambda payload: [Template.from_json(x) for x in (payload or [])])
+
+    def template(self, name: str):
+        """
+        Template by name
+        """
+        params = [name, ]
+        method = "TincWeb.Template"
+        self.__add_request(method, params, lambda payload: Template.from_json(payload))
+
+    def save_template(self, template: Template):
+        """
+        Create or replace template
+        """
+        params = [template.to_json(), ]
+        method = "TincWeb.SaveTemplate"
+        self.__add_request(method, params, lambda payload: Template.from_json(payload))
+
+    def remove_template(self, name: str):
+        """
+        Remove template (returns true if template existed)
+        """
+        params = [name, ]
+        method = "TincWeb.RemoveTemplate"
+        self.__add_request(method, params, lambda payload: payload)
+
+    def trash(self):
+        """
+        Removed networks that could be restored
+        """
+        params = []
+        method = "TincWeb.Trash"
+        self.__add_request(method, params, lambda payload: [TrashItem.from_json(x) for x in (payload or [])])
+
+    def restore(self, id: str):
+        """
+        Restore removed network from trash by item ID
+        """
+        params = [id, ]
+        method = "TincWeb.Restore"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
+    def backup(self):
+        """
+        Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+        """
+        params = []
+        method = "TincWeb.Backup"
+        self.__add_request(method, params, lambda payload: Backup.from_json(payload))
+
+    def restore_backup(self, archive: bytes, options: RestoreOptions):
+        """
+        Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+Running networks are started again after restore
+        """
+        params = [encodebytes(archive), options.to_json(), ]
+        method = "TincWeb.RestoreBackup"
+        self.__add_request(method, params, lambda payload: RestoreResult.from_json(payload))
+
     def start(self, network: str):
         """
         Start or re-start network
@@ -529,6 +1705,14 @@ class TincWebBatch:
         method = "TincWeb.Stop"
         self.__add_request(method, params, lambda payload: Network.from_json(payload))
 
+    def status(self, network: str):
+        """
+        Runtime status of network: process, uptime, restarts, interface and peers
+        """
+        params = [network, ]
+        method = "TincWeb.Status"
+        self.__add_request(method, params, lambda payload: Status.from_json(payload))
+
     def peers(self, network: str):
         """
         Peers brief list in network  (briefly, without config)
@@ -549,20 +1733,41 @@ class TincWebBatch:
         """
         Import another tinc-web network configuration file.
 It means let nodes defined in config join to the network.
-Return created (or used) network with full configuration
+Return created (or used) network with full configuration.
+Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
         """
         params = [sharing.to_json(), ]
         method = "TincWeb.Import"
         self.__add_request(method, params, lambda payload: Network.from_json(payload))
 
+    def import_signed(self, sharing: Sharing, options: ImportOptions):
+        """
+        Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+(code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+Encrypted document is decrypted by passphrase from options before checks.
+Return created (or used) network with full configuration and signer of document
+        """
+        params = [sharing.to_json(), options.to_json(), ]
+        method = "TincWeb.ImportSigned"
+        self.__add_request(method, params, lambda payload: Imported.from_json(payload))
+
     def share(self, network: str):
         """
-        Share network and generate configuration file.
+        Share network and generate configuration file signed by owner key of network.
         """
         params = [network, ]
         method = "TincWeb.Share"
         self.__add_request(method, params, lambda payload: Sharing.from_json(payload))
 
+    def share_encrypted(self, network: str, options: ShareOptions):
+        """
+        Share network and generate signed configuration file encrypted by passphrase from options
+        """
+        params = [network, options.to_json(), ]
+        method = "TincWeb.ShareEncrypted"
+        self.__add_request(method, params, lambda payload: Sharing.from_json(payload))
+
     def node(self, network: str):
         """
         Node definition in network (aka - self node)
@@ -574,28 +1779,150 @@ Return created (or used) network with full configuration
     def upgrade(self, network: str, update: Upgrade):
         """
         Upgrade node parameters.
-In some cases requires restart
+Running network reloads configuration or restarts (if port or device changed) automatically
         """
         params = [network, update.to_json(), ]
         method = "TincWeb.Upgrade"
-        self.__add_request(method, params, lambda payload: Node.from_json(payload))
+        self.__add_request(method, params, lambda payload: UpgradeResult.from_json(payload))
 
     def majordomo(self, network: str, lifetime: Duration):
         """
-        Generate Majordomo request for easy-sharing
+        Generate Majordomo request for easy-sharing (invitation without limits)
         """
         params = [network, lifetime.to_json(), ]
         method = "TincWeb.Majordomo"
         self.__add_request(method, params, lambda payload: payload)
 
+    def invite(self, network: str, options: InviteOptions):
+        """
+        Generate Majordomo link with limited number of uses and optional fixed node name
+        """
+        params = [network, options.to_json(), ]
+        method = "TincWeb.Invite"
+        self.__add_request(method, params, lambda payload: Invite.from_json(payload))
+
+    def invitations(self, network: str):
+        """
+        Invitations to network with redemptions
+        """
+        params = [network, ]
+        method = "TincWeb.Invitations"
+        self.__add_request(method, params, lambda payload: [Invitation.from_json(x) for x in (payload or [])])
+
+    def invitation(self, network: str, id: str):
+        """
+        Invitation to network by ID
+        """
+        params = [network, id, ]
+        method = "TincWeb.Invitation"
+        self.__add_request(method, params, lambda payload: Invitation.from_json(payload))
+
+    def revoke_invitation(self, network: str, id: str):
+        """
+        Revoke invitation, link will not work anymore. Returns true if invitation was active
+        """
+        params = [network, id, ]
+        method = "TincWeb.RevokeInvitation"
+        self.__add_request(method, params, lambda payload: payload)
+
+    def join_requests(self, network: str):
+        """
+        Requests to join network by invitations which require approval
+        """
+        params = [network, ]
+        method = "TincWeb.JoinRequests"
+        self.__add_request(method, params, lambda payload: [JoinRequest.from_json(x) for x in (payload or [])])
+
+    def approve_join(self, network: str, id: str):
+        """
+        Approve join request. Waiting node joins network on next poll
+        """
+        params = [network, id, ]
+        method = "TincWeb.ApproveJoin"
+        self.__add_request(method, params, lambda payload: JoinRequest.from_json(payload))
+
+    def reject_join(self, network: str, id: str):
+        """
+        Reject join request
+        """
+        params = [network, id, ]
+        method = "TincWeb.RejectJoin"
+        self.__add_request(method, params, lambda payload: JoinRequest.from_json(payload))
+
     def join(self, url: str, start: bool):
         """
-        Join by Majordomo Link
+        Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+of inviting node (or rejected, or invitation expired)
         """
         params = [url, start, ]
         method = "TincWeb.Join"
         self.__add_request(method, params, lambda payload: Network.from_json(payload))
 
+    def enable_autostart(self, network: str):
+        """
+        Start network automatically with tinc-web-boot
+        """
+        params = [network, ]
+        method = "TincWeb.EnableAutostart"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
+    def disable_autostart(self, network: str):
+        """
+        Do not start network automatically with tinc-web-boot
+        """
+        params = [network, ]
+        method = "TincWeb.DisableAutostart"
+        self.__add_request(method, params, lambda payload: Network.from_json(payload))
+
+    def autostart_networks(self):
+        """
+        Names of networks that will be started automatically
+        """
+        params = []
+        method = "TincWeb.AutostartNetworks"
+        self.__add_request(method, params, lambda payload: payload or [])
+
+    def restart_policy(self, network: str):
+        """
+        Effective policy of tincd restarts after unexpected exit
+        """
+        params = [network, ]
+        method = "TincWeb.RestartPolicy"
+        self.__add_request(method, params, lambda payload: RestartPolicy.from_json(payload))
+
+    def set_restart_policy(self, network: str, policy: RestartPolicy):
+        """
+        Set custom restart policy for the network. Empty mode resets policy to default.
+Applied immediately for running network
+        """
+        params = [network, policy.to_json(), ]
+        method = "TincWeb.SetRestartPolicy"
+        self.__add_request(method, params, lambda payload: RestartPolicy.from_json(payload))
+
+    def leases(self, network: str):
+        """
+        Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
+        """
+        params = [network, ]
+        method = "TincWeb.Leases"
+        self.__add_request(method, params, lambda payload: [Lease.from_json(x) for x in (payload or [])])
+
+    def set_lease(self, network: str, node: str, ip: str):
+        """
+        Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
+        """
+        params = [network, node, ip, ]
+        method = "TincWeb.SetLease"
+        self.__add_request(method, params, lambda payload: Lease.from_json(payload))
+
+    def remove_lease(self, network: str, node: str):
+        """
+        Remove address lease of node (returns true if lease existed)
+        """
+        params = [network, node, ]
+        method = "TincWeb.RemoveLease"
+        self.__add_request(method, params, lambda payload: payload)
+
     def __add_request(self, method: str, params, factory):
         request_id = self.__next_id()
         request = {
@@ -3,6 +3,7 @@ from aiohttp import client
 from dataclasses import dataclass
 
 from typing import Any, List, Optional
+from base64 import decodebytes, encodebytes
 
 
 
@@ -64,12 +65,16 @@ class Sharing:
     name: 'str'
     subnet: 'str'
     nodes: 'Optional[List[Node]]'
+    signature: 'Optional[Signature]'
+    encrypted: 'Optional[Encrypted]'
 
     def to_json(self) -> dict:
         return {
             "name": self.name,
             "subnet": self.subnet,
             "node": [x.to_json() for x in self.nodes],
+            "signature": self.signature.to_json(),
+            "encrypted": self.encrypted.to_json(),
         }
 
     @staticmethod
@@ -78,6 +83,86 @@ class Sharing:
                 name=payload['name'],
                 subnet=payload['subnet'],
                 nodes=[Node.from_json(x) for x in (payload['node'] or [])],
+                signature=Signature.from_json(payload['signature']),
+                encrypted=Encrypted.from_json(payload['encrypted']),
+        )
+
+
+@dataclass
+class Signature:
+    signer: 'str'
+    key: 'bytes'
+    value: 'bytes'
+
+    def to_json(self) -> dict:
+        return {
+            "signer": self.signer,
+            "key": encodebytes(self.key),
+            "value": encodebytes(self.value),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Signature':
+        return Signature(
+                signer=payload['signer'],
+                key=decodebytes((payload['key'] or '').encode()),
+                value=decodebytes((payload['value'] or '').encode()),
+        )
+
+
+@dataclass
+class Encrypted:
+    format: 'str'
+    kdf: 'KDFParams'
+    cipher: 'str'
+    nonce: 'bytes'
+    data: 'bytes'
+
+    def to_json(self) -> dict:
+        return {
+            "format": self.format,
+            "kdf": self.kdf.to_json(),
+            "cipher": self.cipher,
+            "nonce": encodebytes(self.nonce),
+            "data": encodebytes(self.data),
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Encrypted':
+        return Encrypted(
+                format=payload['format'],
+                kdf=KDFParams.from_json(payload['kdf']),
+                cipher=payload['cipher'],
+                nonce=decodebytes((payload['nonce'] or '').encode()),
+                data=decodebytes((payload['data'] or '').encode()),
+        )
+
+
+@dataclass
+class KDFParams:
+    name: 'str'
+    salt: 'bytes'
+    n: 'int'
+    r: 'int'
+    p: 'int'
+
+    def to_json(self) -> dict:
+        return {
+            "name": self.name,
+            "salt": encodebytes(self.salt),
+            "n": self.n,
+            "r": self.r,
+            "p": self.p,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'KDFParams':
+        return KDFParams(
+                name=payload['name'],
+                salt=decodebytes((payload['salt'] or '').encode()),
+                n=payload['n'],
+                r=payload['r'],
+                p=payload['p'],
         )
 
 
@@ -114,7 +199,10 @@ class TincWebMajordomoClient:
 
     async def join(self, network: str, self: Node) -> Sharing:
         """
-        Join public network if code matched. Will generate error if node subnet not matched
+        Join public network if code matched. Node receives free address inside network subnet
+(returned sharing contains node with assigned address).
+Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
         """
         response = await self._invoke({
             "jsonrpc": "2.0",
@@ -150,7 +238,10 @@ class TincWebMajordomoBatch:
 
     def join(self, network: str, self: Node):
         """
-        Join public network if code matched. Will generate error if node subnet not matched
+        Join public network if code matched. Node receives free address inside network subnet
+(returned sharing contains node with assigned address).
+Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
         """
         params = [network, self.to_json(), ]
         method = "TincWebMajordomo.Join"
@@ -3,7 +3,36 @@ from aiohttp import client
 from dataclasses import dataclass
 
 from enum import Enum
-from typing import List, Optional
+from typing import Any, List, Optional
+
+
+class Role(Enum):
+    ROLE_ADMIN = "admin"
+    ROLE_OPERATOR = "operator"
+    ROLE_VIEWER = "viewer"
+    ROLE_MAJORDOMO = "majordomo"
+
+    def to_json(self) -> str:
+        return self.value
+
+    @staticmethod
+    def from_json(payload: str) -> 'Role':
+        return Role(payload)
+
+
+class Duration(Enum):
+    MIN_DURATION = -1 << 63
+    MAX_DURATION = 1<<63 - 1
+    MIN_DURATION = -1 << 63
+    MAX_DURATION = 1<<63 - 1
+    NANOSECOND = 1
+
+    def to_json(self) -> int:
+        return self.value
+
+    @staticmethod
+    def from_json(payload: int) -> 'Duration':
+        return Duration(payload)
 
 
 class EndpointKind(Enum):
@@ -19,6 +48,56 @@ class EndpointKind(Enum):
 
 
 
+@dataclass
+class Token:
+    id: 'str'
+    label: 'Optional[str]'
+    role: 'Role'
+    issued: 'Any'
+    expires: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "id": self.id,
+            "label": self.label,
+            "role": self.role.to_json(),
+            "issued": self.issued,
+            "expires": self.expires,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'Token':
+        return Token(
+                id=payload['id'],
+                label=payload['label'],
+                role=Role.from_json(payload['role']),
+                issued=payload['issued'],
+                expires=payload['expires'],
+        )
+
+
+@dataclass
+class SigningKey:
+    id: 'str'
+    created: 'Any'
+    expires: 'Any'
+
+    def to_json(self) -> dict:
+        return {
+            "id": self.id,
+            "created": self.created,
+            "expires": self.expires,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'SigningKey':
+        return SigningKey(
+                id=payload['id'],
+                created=payload['created'],
+                expires=payload['expires'],
+        )
+
+
 @dataclass
 class Endpoint:
     host: 'str'
@@ -57,6 +136,34 @@ class Config:
         )
 
 
+@dataclass
+class TincBinary:
+    path: 'str'
+    version: 'Optional[str]'
+    error: 'Optional[str]'
+    default: 'bool'
+    networks: 'Optional[List[str]]'
+
+    def to_json(self) -> dict:
+        return {
+            "path": self.path,
+            "version": self.version,
+            "error": self.error,
+            "default": self.default,
+            "networks": self.networks,
+        }
+
+    @staticmethod
+    def from_json(payload: dict) -> 'TincBinary':
+        return TincBinary(
+                path=payload['path'],
+                version=payload['version'],
+                error=payload['error'],
+                default=payload['default'],
+                networks=payload['networks'] or [],
+        )
+
+
 class TincWebUIError(RuntimeError):
     def __init__(self, method: str, code: int, message: str, data: Any):
         super().__init__('{}: {}: {} - {}'.format(method, code, message, data))
@@ -104,6 +211,87 @@ class TincWebUIClient:
             raise TincWebUIError.from_json('issue_access_token', payload['error'])
         return payload['result']
 
+    async def issue_token(self, role: Role, label: str, valid_days: int) -> str:
+        """
+        Issue and sign token for role (admin, operator or viewer) with optional label
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.IssueToken",
+            "id": self.__next_id(),
+            "params": [role.to_json(), label, valid_days, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('issue_token', payload['error'])
+        return payload['result']
+
+    async def list_tokens(self) -> List[Token]:
+        """
+        Issued and not expired tokens including majordomo links
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.ListTokens",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('list_tokens', payload['error'])
+        return [Token.from_json(x) for x in (payload['result'] or [])]
+
+    async def revoke_token(self, id: str) -> bool:
+        """
+        Revoke token by ID. Returns true if token existed
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.RevokeToken",
+            "id": self.__next_id(),
+            "params": [id, ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('revoke_token', payload['error'])
+        return payload['result']
+
+    async def signing_keys(self) -> List[SigningKey]:
+        """
+        Active signing keys, first one is current
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.SigningKeys",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('signing_keys', payload['error'])
+        return [SigningKey.from_json(x) for x in (payload['result'] or [])]
+
+    async def rotate_key(self, grace: Duration) -> List[SigningKey]:
+        """
+        Generate new signing key. Previous keys are valid for verification during grace period.
+Returns active signing keys
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.RotateKey",
+            "id": self.__next_id(),
+            "params": [grace.to_json(), ]
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('rotate_key', payload['error'])
+        return [SigningKey.from_json(x) for x in (payload['result'] or [])]
+
     async def notify(self, title: str, message: str) -> bool:
         """
         Make desktop notification if system supports it
@@ -152,6 +340,22 @@ class TincWebUIClient:
             raise TincWebUIError.from_json('configuration', payload['error'])
         return Config.from_json(payload['result'])
 
+    async def binaries(self) -> List[TincBinary]:
+        """
+        Tincd binaries (default and per-network) with detected versions
+        """
+        response = await self._invoke({
+            "jsonrpc": "2.0",
+            "method": "TincWebUI.Binaries",
+            "id": self.__next_id(),
+            "params": []
+        })
+        assert response.status // 100 == 2, str(response.status) + " " + str(response.reason)
+        payload = await response.json()
+        if 'error' in payload:
+            raise TincWebUIError.from_json('binaries', payload['error'])
+        return [TincBinary.from_json(x) for x in (payload['result'] or [])]
+
     async def _invoke(self, request):
         return await self.__request('POST', self.__url, json=request)
 
@@ -180,6 +384,47 @@ class TincWebUIBatch:
         method = "TincWebUI.IssueAccessToken"
         self.__add_request(method, params, lambda payload: payload)
 
+    def issue_token(self, role: Role, label: str, valid_days: int):
+        """
+        Issue and sign token for role (admin, operator or viewer) with optional label
+        """
+        params = [role.to_json(), label, valid_days, ]
+        method = "TincWebUI.IssueToken"
+        self.__add_request(method, params, lambda payload: payload)
+
+    def list_tokens(self):
+        """
+        Issued and not expired tokens including majordomo links
+        """
+        params = []
+        method = "TincWebUI.ListTokens"
+        self.__add_request(method, params, lambda payload: [Token.from_json(x) for x in (payload or [])])
+
+    def revoke_token(self, id: str):
+        """
+        Revoke token by ID. Returns true if token existed
+        """
+        params = [id, ]
+        method = "TincWebUI.RevokeToken"
+        self.__add_request(method, params, lambda payload: payload)
+
+    def signing_keys(self):
+        """
+        Active signing keys, first one is current
+        """
+        params = []
+        method = "TincWebUI.SigningKeys"
+        self.__add_request(method, params, lambda payload: [SigningKey.from_json(x) for x in (payload or [])])
+
+    def rotate_key(self, grace: Duration):
+        """
+        Generate new signing key. Previous keys are valid for verification during grace period.
+Returns active signing keys
+        """
+        params = [grace.to_json(), ]
+        method = "TincWebUI.RotateKey"
+        self.__add_request(method, params, lambda payload: [SigningKey.from_json(x) for x in (payload or [])])
+
     def notify(self, title: str, message: str):
         """
         Make desktop notification if system supports it
@@ -204,6 +449,14 @@ class TincWebUIBatch:
         method = "TincWebUI.Configuration"
         self.__add_request(method, params, lambda payload: Config.from_json(payload))
 
+    def binaries(self):
+        """
+        Tincd binaries (default and per-network) with detected versions
+        """
+        params = []
+        method = "TincWebUI.Binaries"
+        self.__add_request(method, params, lambda payload: [TincBinary.from_json(x) for x in (payload or [])])
+
     def __add_request(self, method: str, params, factory):
         request_id = self.__next_id()
         request = {
@@ -9,9 +9,34 @@ export interface PeerID {
     address: string | null
 }
 
+export interface Restarting {
+    network: string
+    attempt: number
+    delay: number
+    reason: string | null
+}
+
+export interface GaveUp {
+    network: string
+    restarts: number
+    reason: string | null
+}
 
-export type EventName = 'Started' | 'Stopped' | 'PeerDiscovered' | 'PeerJoined' | 'PeerLeft';
-export type EventPayload = NetworkID | PeerID;
+export interface NetworkChanged {
+    network: string
+}
+
+export interface HostsChanged {
+    network: string
+    added: Array<string> | null
+    removed: Array<string> | null
+    updated: Array<string> | null
+    reloaded: boolean
+}
+
+
+export type EventName = 'Started' | 'Stopped' | 'PeerDiscovered' | 'PeerJoined' | 'PeerLeft' | 'Restarting' | 'GaveUp' | 'NetworkAdded' | 'NetworkRemoved' | 'HostsChanged';
+export type EventPayload = NetworkID | PeerID | Restarting | GaveUp | NetworkChanged | HostsChanged;
 export type EventHandler = ((payload: EventPayload, event: EventName) => (void)) | ((payload: EventPayload) => (void))
 
 export class Events {
@@ -76,6 +101,51 @@ export class Events {
     }
 
 
+    onRestarting(handler: ((payload: Restarting) => (void)) | ((payload: Restarting, event: EventName) => (void))) {
+        this.listeners.set('Restarting', handler as EventHandler);
+    }
+
+    offRestarting(handler: ((payload: Restarting) => (void)) | ((payload: Restarting, event: EventName) => (void))) {
+        this.listeners.delete('Restarting');
+    }
+
+
+    onGaveUp(handler: ((payload: GaveUp) => (void)) | ((payload: GaveUp, event: EventName) => (void))) {
+        this.listeners.set('GaveUp', handler as EventHandler);
+    }
+
+    offGaveUp(handler: ((payload: GaveUp) => (void)) | ((payload: GaveUp, event: EventName) => (void))) {
+        this.listeners.delete('GaveUp');
+    }
+
+
+    onNetworkAdded(handler: ((payload: NetworkChanged) => (void)) | ((payload: NetworkChanged, event: EventName) => (void))) {
+        this.listeners.set('NetworkAdded', handler as EventHandler);
+    }
+
+    offNetworkAdded(handler: ((payload: NetworkChanged) => (void)) | ((payload: NetworkChanged, event: EventName) => (void))) {
+        this.listeners.delete('NetworkAdded');
+    }
+
+
+    onNetworkRemoved(handler: ((payload: NetworkChanged) => (void)) | ((payload: NetworkChanged, event: EventName) => (void))) {
+        this.listeners.set('NetworkRemoved', handler as EventHandler);
+    }
+
+    offNetworkRemoved(handler: ((payload: NetworkChanged) => (void)) | ((payload: NetworkChanged, event: EventName) => (void))) {
+        this.listeners.delete('NetworkRemoved');
+    }
+
+
+    onHostsChanged(handler: ((payload: HostsChanged) => (void)) | ((payload: HostsChanged, event: EventName) => (void))) {
+        this.listeners.set('HostsChanged', handler as EventHandler);
+    }
+
+    offHostsChanged(handler: ((payload: HostsChanged) => (void)) | ((payload: HostsChanged, event: EventName) => (void))) {
+        this.listeners.delete('HostsChanged');
+    }
+
+
 
     stop() {
         this.stopped = true;
@@ -13,6 +13,7 @@ export class TincWebError extends Error {
 export interface Network {
     name: string
     running: boolean
+    autostart: boolean
     config: Config | null
 }
 
@@ -28,6 +29,74 @@ export interface Config {
     broadcast: string
 }
 
+export interface Template {
+    name: string
+    subnet: string | null
+    device_type: string | null
+    mode: string | null
+    min_port: number | null
+    max_port: number | null
+    address: Array<Address> | null
+    compression: number | null
+    scripts: any | null
+}
+
+export interface Address {
+    host: string
+    port: number | null
+}
+
+export interface TrashItem {
+    id: string
+    network: string
+    removed: Time
+    expires: Time
+}
+
+export interface Backup {
+    manifest: BackupManifest
+    archive: Array<number>
+}
+
+export interface BackupManifest {
+    version: number
+    created: Time
+    networks: Array<BackupNetwork>
+}
+
+export interface BackupNetwork {
+    name: string
+    node: string
+    subnet: string
+    autostart: boolean
+    files: Array<string>
+}
+
+export interface RestoreOptions {
+    networks: Array<string> | null
+    skip_existing: boolean | null
+}
+
+export interface RestoreResult {
+    restored: Array<string>
+    skipped: Array<string> | null
+}
+
+export interface Status {
+    network: string
+    running: boolean
+    pid: number | null
+    started: Time | null
+    uptime: Duration | null
+    restarts: number
+    last_error: string | null
+    interface: string | null
+    addresses: Array<string> | null
+    port: number
+    known_peers: number
+    online_peers: number
+}
+
 export interface PeerInfo {
     name: string
     online: boolean
@@ -44,15 +113,51 @@ export interface Node {
     version: number
 }
 
-export interface Address {
-    host: string
-    port: number | null
-}
-
 export interface Sharing {
     name: string
     subnet: string
     node: Array<Node> | null
+    signature: Signature | null
+    encrypted: Encrypted | null
+}
+
+export interface Signature {
+    signer: string
+    key: Array<number>
+    value: Array<number>
+}
+
+export interface Encrypted {
+    format: string
+    kdf: KDFParams
+    cipher: string
+    nonce: Array<number>
+    data: Array<number>
+}
+
+export interface KDFParams {
+    name: string
+    salt: Array<number>
+    n: number
+    r: number
+    p: number
+}
+
+export interface ImportOptions {
+    name: string | null
+    signer: string | null
+    force: boolean | null
+    passphrase: string | null
+}
+
+export interface Imported {
+    network: Network
+    signer: string | null
+    trusted: boolean
+}
+
+export interface ShareOptions {
+    passphrase: string
 }
 
 export interface Upgrade {
@@ -61,10 +166,81 @@ export interface Upgrade {
     device: string | null
 }
 
+export interface UpgradeResult {
+    node: Node
+    restart_required: boolean
+    reloaded: boolean
+}
+
+export interface Invite {
+    link: string
+    invitation: Invitation
+}
+
+export interface Invitation {
+    id: string
+    node_name: string | null
+    max_uses: number | null
+    approval: boolean | null
+    created: Time
+    expires: Time
+    revoked: boolean | null
+    redemptions: Array<Redemption> | null
+}
+
+export interface Redemption {
+    node: string
+    address: string | null
+    time: Time
+}
+
+export interface InviteOptions {
+    lifetime: Duration
+    max_uses: number | null
+    node_name: string | null
+    approval: boolean | null
+}
+
+export interface JoinRequest {
+    id: string
+    invitation: string
+    node: Node
+    address: string | null
+    status: JoinStatus
+    created: Time
+    expires: Time
+}
+
+export interface RestartPolicy {
+    mode: RestartMode
+    min_backoff: Duration | null
+    max_backoff: Duration | null
+    max_restarts: number | null
+    window: Duration | null
+}
+
+export interface Lease {
+    node: string
+    ip: string
+    updated: Time
+}
+
 
 
 export type Duration = string; // suffixes: ns, us, ms, s, m, h
 
+export enum JoinStatus {
+    JoinPending = "pending",
+    JoinApproved = "approved",
+    JoinRejected = "rejected",
+}
+
+export enum RestartMode {
+    RestartNever = "never",
+    RestartOnFailure = "on-failure",
+    RestartAlways = "always",
+}
+
 
 // support stuff
 
@@ -251,7 +427,8 @@ export class TincWeb {
     }
 
     /**
-    Create new network if not exists
+    Create new network if not exists. Empty subnet means automatically allocated free subnet.
+Subnet should not overlap with other networks and host routes
     **/
     async create(name: string, subnet: string): Promise<Network> {
         return (await this.__call({
@@ -263,7 +440,46 @@ export class TincWeb {
     }
 
     /**
-    Remove network (returns true if network existed)
+    Create new network if not exists with settings from template (or default settings if template name is empty).
+Empty subnet means subnet from template or automatically allocated free subnet
+    **/
+    async createFromTemplate(name: string, subnet: string, template: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.CreateFromTemplate",
+            "id" : this.__next_id(),
+            "params" : [name, subnet, template]
+        })) as Network;
+    }
+
+    /**
+    Rename network. Running network is restarted, autostart and interface name are kept
+    **/
+    async rename(network: string, newName: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Rename",
+            "id" : this.__next_id(),
+            "params" : [network, newName]
+        })) as Network;
+    }
+
+    /**
+    Create new network with settings of existent network (keys and self node are generated).
+Empty subnet means automatically allocated free subnet
+    **/
+    async clone(source: string, network: string, subnet: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Clone",
+            "id" : this.__next_id(),
+            "params" : [source, network, subnet]
+        })) as Network;
+    }
+
+    /**
+    Remove network (returns true if network existed).
+Network is stopped and moved to trash, from where it could be restored during retention period
     **/
     async remove(network: string): Promise<boolean> {
         return (await this.__call({
@@ -274,6 +490,103 @@ export class TincWeb {
         })) as boolean;
     }
 
+    /**
+    Templates for new networks
+    **/
+    async templates(): Promise<Array<Template>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Templates",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<Template>;
+    }
+
+    /**
+    Template by name
+    **/
+    async template(name: string): Promise<Template> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Template",
+            "id" : this.__next_id(),
+            "params" : [name]
+        })) as Template;
+    }
+
+    /**
+    Create or replace template
+    **/
+    async saveTemplate(template: Template): Promise<Template> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SaveTemplate",
+            "id" : this.__next_id(),
+            "params" : [template]
+        })) as Template;
+    }
+
+    /**
+    Remove template (returns true if template existed)
+    **/
+    async removeTemplate(name: string): Promise<boolean> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RemoveTemplate",
+            "id" : this.__next_id(),
+            "params" : [name]
+        })) as boolean;
+    }
+
+    /**
+    Removed networks that could be restored
+    **/
+    async trash(): Promise<Array<TrashItem>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Trash",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<TrashItem>;
+    }
+
+    /**
+    Restore removed network from trash by item ID
+    **/
+    async restore(id: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Restore",
+            "id" : this.__next_id(),
+            "params" : [id]
+        })) as Network;
+    }
+
+    /**
+    Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+    **/
+    async backup(): Promise<Backup> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Backup",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Backup;
+    }
+
+    /**
+    Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+Running networks are started again after restore
+    **/
+    async restoreBackup(archive: Array<number>, options: RestoreOptions): Promise<RestoreResult> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RestoreBackup",
+            "id" : this.__next_id(),
+            "params" : [archive, options]
+        })) as RestoreResult;
+    }
+
     /**
     Start or re-start network
     **/
@@ -298,6 +611,18 @@ export class TincWeb {
         })) as Network;
     }
 
+    /**
+    Runtime status of network: process, uptime, restarts, interface and peers
+    **/
+    async status(network: string): Promise<Status> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Status",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Status;
+    }
+
     /**
     Peers brief list in network  (briefly, without config)
     **/
@@ -325,7 +650,8 @@ export class TincWeb {
     /**
     Import another tinc-web network configuration file.
 It means let nodes defined in config join to the network.
-Return created (or used) network with full configuration
+Return created (or used) network with full configuration.
+Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
     **/
     async import(sharing: Sharing): Promise<Network> {
         return (await this.__call({
@@ -337,7 +663,23 @@ Return created (or used) network with full configuration
     }
 
     /**
-    Share network and generate configuration file.
+    Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+(code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+Encrypted document is decrypted by passphrase from options before checks.
+Return created (or used) network with full configuration and signer of document
+    **/
+    async importSigned(sharing: Sharing, options: ImportOptions): Promise<Imported> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ImportSigned",
+            "id" : this.__next_id(),
+            "params" : [sharing, options]
+        })) as Imported;
+    }
+
+    /**
+    Share network and generate configuration file signed by owner key of network.
     **/
     async share(network: string): Promise<Sharing> {
         return (await this.__call({
@@ -348,6 +690,18 @@ Return created (or used) network with full configuration
         })) as Sharing;
     }
 
+    /**
+    Share network and generate signed configuration file encrypted by passphrase from options
+    **/
+    async shareEncrypted(network: string, options: ShareOptions): Promise<Sharing> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ShareEncrypted",
+            "id" : this.__next_id(),
+            "params" : [network, options]
+        })) as Sharing;
+    }
+
     /**
     Node definition in network (aka - self node)
     **/
@@ -362,19 +716,19 @@ Return created (or used) network with full configuration
 
     /**
     Upgrade node parameters.
-In some cases requires restart
+Running network reloads configuration or restarts (if port or device changed) automatically
     **/
-    async upgrade(network: string, update: Upgrade): Promise<Node> {
+    async upgrade(network: string, update: Upgrade): Promise<UpgradeResult> {
         return (await this.__call({
             "jsonrpc" : "2.0",
             "method" : "TincWeb.Upgrade",
             "id" : this.__next_id(),
             "params" : [network, update]
-        })) as Node;
+        })) as UpgradeResult;
     }
 
     /**
-    Generate Majordomo request for easy-sharing
+    Generate Majordomo request for easy-sharing (invitation without limits)
     **/
     async majordomo(network: string, lifetime: Duration): Promise<string> {
         return (await this.__call({
@@ -386,7 +740,92 @@ In some cases requires restart
     }
 
     /**
-    Join by Majordomo Link
+    Generate Majordomo link with limited number of uses and optional fixed node name
+    **/
+    async invite(network: string, options: InviteOptions): Promise<Invite> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invite",
+            "id" : this.__next_id(),
+            "params" : [network, options]
+        })) as Invite;
+    }
+
+    /**
+    Invitations to network with redemptions
+    **/
+    async invitations(network: string): Promise<Array<Invitation>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invitations",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Array<Invitation>;
+    }
+
+    /**
+    Invitation to network by ID
+    **/
+    async invitation(network: string, id: string): Promise<Invitation> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Invitation",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        })) as Invitation;
+    }
+
+    /**
+    Revoke invitation, link will not work anymore. Returns true if invitation was active
+    **/
+    async revokeInvitation(network: string, id: string): Promise<boolean> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RevokeInvitation",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        })) as boolean;
+    }
+
+    /**
+    Requests to join network by invitations which require approval
+    **/
+    async joinRequests(network: string): Promise<Array<JoinRequest>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.JoinRequests",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Array<JoinRequest>;
+    }
+
+    /**
+    Approve join request. Waiting node joins network on next poll
+    **/
+    async approveJoin(network: string, id: string): Promise<JoinRequest> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.ApproveJoin",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        })) as JoinRequest;
+    }
+
+    /**
+    Reject join request
+    **/
+    async rejectJoin(network: string, id: string): Promise<JoinRequest> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RejectJoin",
+            "id" : this.__next_id(),
+            "params" : [network, id]
+        })) as JoinRequest;
+    }
+
+    /**
+    Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+of inviting node (or rejected, or invitation expired)
     **/
     async join(url: string, start: boolean): Promise<Network> {
         return (await this.__call({
@@ -397,6 +836,103 @@ In some cases requires restart
         })) as Network;
     }
 
+    /**
+    Start network automatically with tinc-web-boot
+    **/
+    async enableAutostart(network: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.EnableAutostart",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Network;
+    }
+
+    /**
+    Do not start network automatically with tinc-web-boot
+    **/
+    async disableAutostart(network: string): Promise<Network> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.DisableAutostart",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Network;
+    }
+
+    /**
+    Names of networks that will be started automatically
+    **/
+    async autostartNetworks(): Promise<Array<string>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.AutostartNetworks",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<string>;
+    }
+
+    /**
+    Effective policy of tincd restarts after unexpected exit
+    **/
+    async restartPolicy(network: string): Promise<RestartPolicy> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RestartPolicy",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as RestartPolicy;
+    }
+
+    /**
+    Set custom restart policy for the network. Empty mode resets policy to default.
+Applied immediately for running network
+    **/
+    async setRestartPolicy(network: string, policy: RestartPolicy): Promise<RestartPolicy> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SetRestartPolicy",
+            "id" : this.__next_id(),
+            "params" : [network, policy]
+        })) as RestartPolicy;
+    }
+
+    /**
+    Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
+    **/
+    async leases(network: string): Promise<Array<Lease>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.Leases",
+            "id" : this.__next_id(),
+            "params" : [network]
+        })) as Array<Lease>;
+    }
+
+    /**
+    Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
+    **/
+    async setLease(network: string, node: string, ip: string): Promise<Lease> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.SetLease",
+            "id" : this.__next_id(),
+            "params" : [network, node, ip]
+        })) as Lease;
+    }
+
+    /**
+    Remove address lease of node (returns true if lease existed)
+    **/
+    async removeLease(network: string, node: string): Promise<boolean> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWeb.RemoveLease",
+            "id" : this.__next_id(),
+            "params" : [network, node]
+        })) as boolean;
+    }
+
 
     private __next_id() {
         this.__id += 1;
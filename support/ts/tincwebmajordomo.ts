@@ -29,6 +29,30 @@ export interface Sharing {
     name: string
     subnet: string
     node: Array<Node> | null
+    signature: Signature | null
+    encrypted: Encrypted | null
+}
+
+export interface Signature {
+    signer: string
+    key: Array<number>
+    value: Array<number>
+}
+
+export interface Encrypted {
+    format: string
+    kdf: KDFParams
+    cipher: string
+    nonce: Array<number>
+    data: Array<number>
+}
+
+export interface KDFParams {
+    name: string
+    salt: Array<number>
+    n: number
+    r: number
+    p: number
 }
 
 
@@ -195,7 +219,10 @@ export class TincWebMajordomo {
 
 
     /**
-    Join public network if code matched. Will generate error if node subnet not matched
+    Join public network if code matched. Node receives free address inside network subnet
+(returned sharing contains node with assigned address).
+Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
     **/
     async join(network: string, self: Node): Promise<Sharing> {
         return (await this.__call({
@@ -10,6 +10,20 @@ export class TincWebUIError extends Error {
 }
 
 
+export interface Token {
+    id: string
+    label: string | null
+    role: Role
+    issued: Time
+    expires: Time
+}
+
+export interface SigningKey {
+    id: string
+    created: Time
+    expires: Time
+}
+
 export interface Endpoint {
     host: string
     port: number
@@ -20,7 +34,24 @@ export interface Config {
     binding: string
 }
 
+export interface TincBinary {
+    path: string
+    version: string | null
+    error: string | null
+    default: boolean
+    networks: Array<string> | null
+}
+
+
 
+export enum Role {
+    RoleAdmin = "admin",
+    RoleOperator = "operator",
+    RoleViewer = "viewer",
+    RoleMajordomo = "majordomo",
+}
+
+export type Duration = string; // suffixes: ns, us, ms, s, m, h
 
 export enum EndpointKind {
     Local = "local",
@@ -200,6 +231,67 @@ export class TincWebUI {
         })) as string;
     }
 
+    /**
+    Issue and sign token for role (admin, operator or viewer) with optional label
+    **/
+    async issueToken(role: Role, label: string, validDays: number): Promise<string> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.IssueToken",
+            "id" : this.__next_id(),
+            "params" : [role, label, validDays]
+        })) as string;
+    }
+
+    /**
+    Issued and not expired tokens including majordomo links
+    **/
+    async listTokens(): Promise<Array<Token>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.ListTokens",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<Token>;
+    }
+
+    /**
+    Revoke token by ID. Returns true if token existed
+    **/
+    async revokeToken(id: string): Promise<boolean> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.RevokeToken",
+            "id" : this.__next_id(),
+            "params" : [id]
+        })) as boolean;
+    }
+
+    /**
+    Active signing keys, first one is current
+    **/
+    async signingKeys(): Promise<Array<SigningKey>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.SigningKeys",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<SigningKey>;
+    }
+
+    /**
+    Generate new signing key. Previous keys are valid for verification during grace period.
+Returns active signing keys
+    **/
+    async rotateKey(grace: Duration): Promise<Array<SigningKey>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.RotateKey",
+            "id" : this.__next_id(),
+            "params" : [grace]
+        })) as Array<SigningKey>;
+    }
+
     /**
     Make desktop notification if system supports it
     **/
@@ -236,6 +328,18 @@ export class TincWebUI {
         })) as Config;
     }
 
+    /**
+    Tincd binaries (default and per-network) with detected versions
+    **/
+    async binaries(): Promise<Array<TincBinary>> {
+        return (await this.__call({
+            "jsonrpc" : "2.0",
+            "method" : "TincWebUI.Binaries",
+            "id" : this.__next_id(),
+            "params" : []
+        })) as Array<TincBinary>;
+    }
+
 
     private __next_id() {
         this.__id += 1;
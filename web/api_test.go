@@ -0,0 +1,1085 @@
+package web_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/reddec/jsonrpc2"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"tinc-web-boot/pool"
+	"tinc-web-boot/pool/simulator"
+	"tinc-web-boot/support/go/tincweb"
+	"tinc-web-boot/support/go/tincwebmajordomo"
+	"tinc-web-boot/support/go/tincwebui"
+	"tinc-web-boot/web"
+	"tinc-web-boot/web/shared"
+)
+
+type uiApp interface {
+	shared.TincWebUI
+	IssueLoginCode() (string, error)
+}
+
+type testServer struct {
+	pool   *pool.Pool
+	runner *simulator.Runner
+	server *httptest.Server
+	client *tincweb.TincWebClient
+	ui     uiApp
+	dir    string
+	cancel func()
+}
+
+func newTestServer(t *testing.T, cfg web.Config) *testServer {
+	dir, err := ioutil.TempDir("", "web-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "networks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := &testServer{
+		runner: &simulator.Runner{},
+		dir:    dir,
+		cancel: cancel,
+	}
+	ts.pool, err = pool.NewWithRunner(ctx, filepath.Join(dir, "tinc-web.json"), filepath.Join(dir, "networks"), "tincd", ts.runner)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	cfg.PublicAddresses = []string{listener.Addr().String()}
+	router, ui := cfg.New(ts.pool)
+	ts.ui = ui
+	ts.server = &httptest.Server{
+		Listener: listener,
+		Config:   &http.Server{Handler: router},
+	}
+	ts.server.Start()
+	ts.client = &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/local"}
+	return ts
+}
+
+func (ts *testServer) Close() {
+	ts.server.Close()
+	ts.pool.Stop()
+	ts.cancel()
+	_ = os.RemoveAll(ts.dir)
+}
+
+func TestAPI_NetworkLifecycle(t *testing.T) {
+	ts := newTestServer(t, web.Config{})
+	defer ts.Close()
+	ctx := context.Background()
+
+	created, err := ts.client.Create(ctx, "alpha", "10.155.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "alpha" || created.Running {
+		t.Errorf("unexpected network: %+v", created)
+	}
+
+	list, err := ts.client.Networks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "alpha" {
+		t.Errorf("unexpected networks: %+v", list)
+	}
+
+	started, err := ts.client.Start(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !started.Running {
+		t.Error("network should be running")
+	}
+
+	self, err := ts.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.runner.Process("alpha").Join(self.Name)
+	peers, err := ts.client.Peers(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || !peers[0].Online {
+		t.Errorf("unexpected peers: %+v", peers)
+	}
+
+	status, err := ts.client.Status(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Running || status.PID != ts.runner.Process("alpha").PID() || status.OnlinePeers != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	stopped, err := ts.client.Stop(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Running {
+		t.Error("network should be stopped")
+	}
+
+	removed, err := ts.client.Remove(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !removed {
+		t.Error("network should be removed")
+	}
+	trash, err := ts.client.Trash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 {
+		t.Fatalf("unexpected trash: %+v", trash)
+	}
+	restored, err := ts.client.Restore(ctx, trash[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Name != "alpha" {
+		t.Errorf("unexpected restored network: %+v", restored)
+	}
+}
+
+func TestAPI_Autostart(t *testing.T) {
+	ts := newTestServer(t, web.Config{})
+	defer ts.Close()
+	ctx := context.Background()
+
+	if _, err := ts.client.EnableAutostart(ctx, "alpha"); err == nil {
+		t.Error("autostart for undefined network should fail")
+	}
+	if _, err := ts.client.Create(ctx, "alpha", "10.155.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := ts.client.EnableAutostart(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Autostart {
+		t.Error("autostart should be enabled")
+	}
+	names, err := ts.client.AutostartNetworks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "alpha" {
+		t.Errorf("unexpected autostart networks: %v", names)
+	}
+	info, err = ts.client.DisableAutostart(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Autostart {
+		t.Error("autostart should be disabled")
+	}
+}
+
+func TestAPI_Majordomo(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	guest := newTestServer(t, web.Config{AuthKey: "guest-key"})
+	defer guest.Close()
+	ctx := context.Background()
+
+	if _, err := owner.client.Create(ctx, "alpha", "10.155.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	ownerNode, err := owner.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := owner.client.Majordomo(ctx, "alpha", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined, err := guest.client.Join(ctx, link, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.Name != "alpha" || !joined.Running {
+		t.Errorf("unexpected joined network: %+v", joined)
+	}
+	guestNode, err := guest.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := owner.client.Peer(ctx, "alpha", guestNode.Name); err != nil {
+		t.Errorf("guest node should be known by owner: %v", err)
+	}
+	leases, err := owner.client.Leases(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != 1 || leases[0].Node != guestNode.Name || leases[0].IP != guestNode.IP {
+		t.Errorf("guest address should be leased by owner: %+v, node %+v", leases, guestNode)
+	}
+	if guestNode.IP == ownerNode.IP {
+		t.Error("guest should not use address of owner")
+	}
+	if _, err := guest.client.Peer(ctx, "alpha", ownerNode.Name); err != nil {
+		t.Errorf("owner node should be known by guest: %v", err)
+	}
+
+	_, err = guest.client.Join(ctx, link[:len(link)-4]+"AAAA", false)
+	if err == nil {
+		t.Error("join with corrupted token should fail")
+	}
+
+	tokens, err := owner.ui.ListTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Role != shared.RoleMajordomo {
+		t.Fatalf("invitation should be registered: %+v", tokens)
+	}
+	if _, err := owner.ui.RevokeToken(ctx, tokens[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Remove(ctx, "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Join(ctx, link, false); err == nil {
+		t.Error("join with revoked invitation should fail")
+	}
+}
+
+func TestAPI_Invitations(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	guest := newTestServer(t, web.Config{AuthKey: "guest-key"})
+	defer guest.Close()
+	ctx := context.Background()
+
+	if _, err := owner.client.Create(ctx, "alpha", "10.156.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	named, err := owner.client.Invite(ctx, "alpha", shared.InviteOptions{Lifetime: time.Hour, NodeName: "someone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Join(ctx, named.Link, false); err == nil {
+		t.Error("join by invitation for another node should fail")
+	}
+
+	invite, err := owner.client.Invite(ctx, "alpha", shared.InviteOptions{Lifetime: time.Hour, MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Join(ctx, invite.Link, false); err != nil {
+		t.Fatal(err)
+	}
+	guestNode, err := guest.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Remove(ctx, "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := guest.client.Join(ctx, invite.Link, false); err == nil {
+		t.Error("second join by single-use invitation should fail")
+	}
+
+	info, err := owner.client.Invitation(ctx, "alpha", invite.Invitation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Redemptions) != 1 || info.Redemptions[0].Node != guestNode.Name || info.IsActive() {
+		t.Errorf("redemption should be recorded: %+v", info)
+	}
+
+	revoked, err := owner.client.RevokeInvitation(ctx, "alpha", named.Invitation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("invitation should be revoked")
+	}
+	list, err := owner.client.Invitations(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].Revoked || list[1].ID != invite.Invitation.ID {
+		t.Errorf("unexpected invitations: %+v", list)
+	}
+	tokens, err := owner.ui.ListTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != invite.Invitation.ID {
+		t.Errorf("token of revoked invitation should be revoked: %+v", tokens)
+	}
+}
+
+func TestAPI_JoinApproval(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	guest := newTestServer(t, web.Config{AuthKey: "guest-key"})
+	defer guest.Close()
+	ctx := context.Background()
+
+	if _, err := owner.client.Create(ctx, "alpha", "10.157.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	invite, err := owner.client.Invite(ctx, "alpha", shared.InviteOptions{Lifetime: time.Hour, Approval: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		network *shared.Network
+		err     error
+	}
+	joined := make(chan result, 1)
+	go func() {
+		ntw, err := guest.client.Join(ctx, invite.Link, false)
+		joined <- result{ntw, err}
+	}()
+
+	var requests []*shared.JoinRequest
+	deadline := time.Now().Add(5 * time.Second)
+	for len(requests) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("join request is not received")
+		}
+		time.Sleep(50 * time.Millisecond)
+		requests, err = owner.client.JoinRequests(ctx, "alpha")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests[0].Status != shared.JoinPending {
+		t.Errorf("request should be pending: %+v", requests[0])
+	}
+	if _, err := owner.client.Peer(ctx, "alpha", requests[0].Node.Name); err == nil {
+		t.Error("node should not be added before approval")
+	}
+	select {
+	case res := <-joined:
+		t.Fatalf("join should wait for approval: %+v", res)
+	default:
+	}
+
+	if _, err := owner.client.ApproveJoin(ctx, "alpha", requests[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-joined:
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		if res.network.Name != "alpha" {
+			t.Errorf("unexpected joined network: %+v", res.network)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("join is not completed after approval")
+	}
+	if _, err := owner.client.Peer(ctx, "alpha", requests[0].Node.Name); err != nil {
+		t.Errorf("approved node should be known by owner: %v", err)
+	}
+	requests, err = owner.client.JoinRequests(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("completed request should be removed: %+v", requests)
+	}
+}
+
+func TestAPI_MajordomoScope(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	ctx := context.Background()
+
+	for _, name := range []string{"alpha", "beta"} {
+		if _, err := owner.client.Create(ctx, name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alpha, err := owner.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite, err := owner.client.Invite(ctx, "alpha", shared.InviteOptions{Lifetime: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &tincwebmajordomo.TincWebMajordomoClient{BaseURL: invite.Link}
+	_, subnet, _ := net.ParseCIDR(alpha.Subnet)
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest := network.Node{
+		Name:      "guest",
+		Subnet:    subnet.String(),
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})),
+	}
+
+	var cases = []struct {
+		name    string
+		network string
+		node    func(node *network.Node)
+		code    int
+	}{
+		{"another network", "beta", nil, web.ForbiddenError},
+		{"invalid name", "alpha", func(node *network.Node) { node.Name = "bad name" }, web.InvalidNodeError},
+		{"no key", "alpha", func(node *network.Node) { node.PublicKey = "" }, web.InvalidNodeError},
+		{"invalid key", "alpha", func(node *network.Node) { node.PublicKey = "key\nPort = 1" }, web.InvalidNodeError},
+		{"invalid address", "alpha", func(node *network.Node) { node.Address = []network.Address{{Host: "a\nPort = 1"}} }, web.InvalidNodeError},
+		{"invalid subnet", "alpha", func(node *network.Node) { node.Subnet = "garbage" }, web.InvalidNodeError},
+		{"foreign subnet", "alpha", func(node *network.Node) { node.Subnet = "192.0.2.0/24" }, web.InvalidNodeError},
+		{"wider subnet", "alpha", func(node *network.Node) { node.Subnet = "10.0.0.0/8" }, web.InvalidNodeError},
+	}
+	for _, c := range cases {
+		node := guest
+		if c.node != nil {
+			c.node(&node)
+		}
+		_, err := remote.Join(ctx, c.network, &node)
+		var rpcErr *jsonrpc2.Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != c.code {
+			t.Errorf("%s: expected error code %d, got %v", c.name, c.code, err)
+		}
+	}
+	if list, err := owner.client.Invitations(ctx, "alpha"); err != nil || len(list[0].Redemptions) != 0 {
+		t.Errorf("rejected joins should not be recorded: %+v %v", list, err)
+	}
+
+	sharing, err := remote.Join(ctx, "alpha", &guest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sharing.Name != "alpha" {
+		t.Errorf("unexpected sharing: %+v", sharing)
+	}
+	joined, err := owner.client.Peer(ctx, "alpha", "guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another key could not take name of member even with newer version
+	otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impostor := guest
+	impostor.Version = joined.Configuration.Version + 10
+	impostor.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&otherKey.PublicKey)}))
+	_, err = remote.Join(ctx, "alpha", &impostor)
+	var rpcErr *jsonrpc2.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != web.ConflictError {
+		t.Errorf("impersonation should be rejected with conflict, got %v", err)
+	}
+	if peer, err := owner.client.Peer(ctx, "alpha", "guest"); err != nil || peer.Configuration.PublicKey != joined.Configuration.PublicKey {
+		t.Errorf("key of member should be kept: %v", err)
+	}
+
+	// re-join with same key and older version updates record instead of silently skipping it
+	rejoin := guest
+	rejoin.Address = []network.Address{{Host: "203.0.113.1", Port: 655}}
+	if _, err := remote.Join(ctx, "alpha", &rejoin); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := owner.client.Peer(ctx, "alpha", "guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Configuration.Address) != 1 || updated.Configuration.Address[0].Host != "203.0.113.1" || updated.Configuration.Version <= joined.Configuration.Version {
+		t.Errorf("re-joined node should be saved: %+v", updated.Configuration)
+	}
+}
+
+func TestAPI_SignedSharing(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	guest := newTestServer(t, web.Config{AuthKey: "guest-key"})
+	defer guest.Close()
+	ctx := context.Background()
+
+	if _, err := owner.client.Create(ctx, "alpha", "10.158.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	sharing, err := owner.client.Share(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sharing.Signature == nil || !strings.HasPrefix(sharing.Signature.Signer, "SHA256:") {
+		t.Fatalf("sharing should be signed: %+v", sharing.Signature)
+	}
+	signer := sharing.Signature.Signer
+
+	_, err = guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{})
+	var rpcErr *jsonrpc2.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != web.ForbiddenError || !strings.Contains(rpcErr.Message, signer) {
+		t.Errorf("untrusted document should be rejected with signer, got %v", err)
+	}
+	if _, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{Signer: "SHA256:other"}); err == nil {
+		t.Error("document of unexpected signer should be rejected")
+	}
+
+	tampered := *sharing
+	tampered.Nodes = append([]*network.Node{{Name: "intruder", Subnet: sharing.Subnet}}, sharing.Nodes...)
+	if _, err := guest.client.ImportSigned(ctx, tampered, shared.ImportOptions{Force: true}); err == nil {
+		t.Error("document with invalid signature should be rejected even if forced")
+	}
+	unsigned := *sharing
+	unsigned.Signature = nil
+	if _, err := guest.client.ImportSigned(ctx, unsigned, shared.ImportOptions{}); err == nil {
+		t.Error("unsigned document should be rejected")
+	}
+
+	imported, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{Signer: signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Network.Name != "alpha" || imported.Signer != signer || !imported.Trusted {
+		t.Errorf("unexpected import result: %+v", imported)
+	}
+	// signer is remembered for next updates
+	updated, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Trusted {
+		t.Errorf("signer of previous import should be trusted: %+v", updated)
+	}
+
+	forced, err := guest.client.ImportSigned(ctx, unsigned, shared.ImportOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forced.Network.Name != "alpha" || forced.Signer != "" || forced.Trusted {
+		t.Errorf("unexpected result of forced import: %+v", forced)
+	}
+
+	// plain import requires trusted signature same as ImportSigned
+	_, err = guest.client.Import(ctx, unsigned)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != web.ForbiddenError {
+		t.Errorf("plain import should reject unsigned document, got %v", err)
+	}
+	if _, err := guest.client.Import(ctx, tampered); err == nil {
+		t.Error("plain import should reject document with invalid signature")
+	}
+	legacy, err := guest.client.Import(ctx, *sharing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.Name != "alpha" {
+		t.Errorf("unexpected network of plain import: %+v", legacy)
+	}
+}
+
+func TestAPI_EncryptedSharing(t *testing.T) {
+	owner := newTestServer(t, web.Config{AuthKey: "owner-key"})
+	defer owner.Close()
+	guest := newTestServer(t, web.Config{AuthKey: "guest-key"})
+	defer guest.Close()
+	ctx := context.Background()
+
+	if _, err := owner.client.Create(ctx, "alpha", "10.159.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.client.ShareEncrypted(ctx, "alpha", shared.ShareOptions{}); err == nil {
+		t.Error("encryption without passphrase should be rejected")
+	}
+	sharing, err := owner.client.ShareEncrypted(ctx, "alpha", shared.ShareOptions{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := sharing.Encrypted
+	if envelope == nil || sharing.Name != "" || len(sharing.Nodes) != 0 || sharing.Signature != nil {
+		t.Fatalf("document should be encrypted: %+v", sharing)
+	}
+	if envelope.Format != "tinc-web-boot/sharing" || envelope.Cipher != "aes-256-gcm" || envelope.KDF.Name != "scrypt" || len(envelope.KDF.Salt) == 0 {
+		t.Errorf("envelope should describe encryption: %+v", envelope)
+	}
+	data, err := json.Marshal(sharing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "alpha") {
+		t.Error("network name should not be visible in encrypted document")
+	}
+
+	if _, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{Force: true}); err == nil {
+		t.Error("encrypted document should not be imported without passphrase")
+	}
+	if _, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{Force: true, Passphrase: "wrong"}); err == nil {
+		t.Error("encrypted document should not be imported with wrong passphrase")
+	}
+	tampered := *sharing
+	corrupted := *envelope
+	corrupted.Data = append([]byte{}, envelope.Data...)
+	corrupted.Data[0] ^= 1
+	tampered.Encrypted = &corrupted
+	if _, err := guest.client.ImportSigned(ctx, tampered, shared.ImportOptions{Force: true, Passphrase: "correct horse"}); err == nil {
+		t.Error("modified document should be rejected")
+	}
+
+	imported, err := guest.client.ImportSigned(ctx, *sharing, shared.ImportOptions{Passphrase: "correct horse", Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Network.Name != "alpha" || imported.Signer == "" {
+		t.Errorf("decrypted document should keep signature: %+v", imported)
+	}
+}
+
+func TestAPI_AuthorizedOnly(t *testing.T) {
+	ts := newTestServer(t, web.Config{AuthorizedOnly: true, AuthKey: "secret"})
+	defer ts.Close()
+	ctx := context.Background()
+
+	if _, err := ts.client.Networks(ctx); err == nil {
+		t.Error("request without valid token should fail")
+	}
+}
+
+func TestAPI_Roles(t *testing.T) {
+	ts := newTestServer(t, web.Config{AuthorizedOnly: true, AuthKey: "secret"})
+	defer ts.Close()
+	ctx := context.Background()
+
+	clients := make(map[shared.Role]*tincweb.TincWebClient)
+	for _, role := range []shared.Role{shared.RoleAdmin, shared.RoleOperator, shared.RoleViewer} {
+		token, err := ts.ui.IssueToken(ctx, role, "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[role] = &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/" + token}
+	}
+	if _, err := ts.ui.IssueToken(ctx, shared.RoleMajordomo, "", 1); err == nil {
+		t.Error("majordomo token should not be issued")
+	}
+
+	if _, err := clients[shared.RoleViewer].Create(ctx, "alpha", "10.155.0.0/16"); !isForbidden(err) {
+		t.Errorf("viewer should not create network: %v", err)
+	}
+	if _, err := clients[shared.RoleOperator].Create(ctx, "alpha", "10.155.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := clients[shared.RoleViewer].Networks(ctx); err != nil || len(list) != 1 {
+		t.Errorf("viewer should list networks: %v %+v", err, list)
+	}
+	if _, err := clients[shared.RoleOperator].Remove(ctx, "alpha"); !isForbidden(err) {
+		t.Errorf("operator should not remove network: %v", err)
+	}
+	if _, err := clients[shared.RoleAdmin].Remove(ctx, "alpha"); err != nil {
+		t.Error(err)
+	}
+
+	viewerToken, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewerUI := &tincwebui.TincWebUIClient{BaseURL: ts.server.URL + "/api/" + viewerToken}
+	if _, err := viewerUI.IssueToken(ctx, shared.RoleAdmin, "", 1); !isForbidden(err) {
+		t.Errorf("viewer should not issue tokens: %v", err)
+	}
+
+	if _, err := clients[shared.RoleAdmin].Create(ctx, "beta", "10.156.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	link, err := clients[shared.RoleOperator].Majordomo(ctx, "beta", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	majordomo := &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/" + link[strings.LastIndex(link, "/")+1:]}
+	if _, err := majordomo.Networks(ctx); err == nil {
+		t.Error("majordomo token should not be accepted by API")
+	}
+}
+
+func TestAPI_Tokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := web.Config{AuthorizedOnly: true, AuthKey: "secret", TokensFile: filepath.Join(dir, "tokens.json")}
+	ts := newTestServer(t, cfg)
+	defer ts.Close()
+	ctx := context.Background()
+
+	if _, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "", 0); err == nil {
+		t.Error("token without lifetime should not be issued")
+	}
+	token, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "monitoring", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := ts.ui.ListTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Label != "monitoring" || list[0].Role != shared.RoleViewer {
+		t.Fatalf("unexpected tokens: %+v", list)
+	}
+	if d := list[0].Expires.Sub(list[0].Issued); d != 24*time.Hour {
+		t.Errorf("unexpected lifetime: %v", d)
+	}
+
+	// registry is persisted: another instance with same key accepts token
+	other := newTestServer(t, cfg)
+	defer other.Close()
+	client := &tincweb.TincWebClient{BaseURL: other.server.URL + "/api/" + token}
+	if _, err := client.Networks(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked, err := other.ui.RevokeToken(ctx, list[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("token should be revoked")
+	}
+	if _, err := client.Networks(ctx); err == nil {
+		t.Error("revoked token should not be accepted")
+	}
+	if list, _ := other.ui.ListTokens(ctx); len(list) != 0 {
+		t.Errorf("revoked token should not be listed: %+v", list)
+	}
+}
+
+func TestAPI_RotateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keysFile := filepath.Join(dir, "auth-keys.json")
+	keys, err := web.LoadKeyring(keysFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(keysFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("keys should be saved with strict permissions: %v %v", info.Mode(), err)
+	}
+	reloaded, err := web.LoadKeyring(keysFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := keys.Current(); id == "" || len(reloaded.Keys()) != 1 || reloaded.Keys()[0].ID != id {
+		t.Fatalf("key should be persisted: %s %+v", id, reloaded.Keys())
+	}
+
+	ts := newTestServer(t, web.Config{AuthorizedOnly: true, Keyring: reloaded, TokensFile: filepath.Join(dir, "tokens.json")})
+	defer ts.Close()
+	ctx := context.Background()
+
+	token, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/" + token}
+
+	list, err := ts.ui.RotateKey(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].Expires.IsZero() || list[1].Expires.IsZero() {
+		t.Fatalf("unexpected keys: %+v", list)
+	}
+	if _, err := client.Networks(ctx); err != nil {
+		t.Errorf("token should be valid during grace period: %v", err)
+	}
+	fresh, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = ts.ui.RotateKey(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("previous keys should be removed without grace period: %+v", list)
+	}
+	if _, err := client.Networks(ctx); err == nil {
+		t.Error("token signed by removed key should not be accepted")
+	}
+	client = &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/" + fresh}
+	if _, err := client.Networks(ctx); err == nil {
+		t.Error("token signed by rotated key should not be accepted after grace period")
+	}
+
+	static := newTestServer(t, web.Config{AuthKey: "secret"})
+	defer static.Close()
+	if _, err := static.ui.RotateKey(ctx, time.Hour); err == nil {
+		t.Error("static key should not be rotated")
+	}
+}
+
+func TestAPI_Session(t *testing.T) {
+	ts := newTestServer(t, web.Config{AuthorizedOnly: true, AuthKey: "secret", NoTokenPath: true})
+	defer ts.Close()
+	ctx := context.Background()
+	const networksCall = `{"jsonrpc":"2.0","id":1,"method":"TincWeb.Networks","params":[]}`
+
+	call := func(header http.Header, cookies ...*http.Cookie) int {
+		req, err := http.NewRequest(http.MethodPost, ts.server.URL+"/rpc", strings.NewReader(networksCall))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	token, err := ts.ui.IssueToken(ctx, shared.RoleViewer, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := call(http.Header{"Authorization": {"Bearer " + token}}); code != http.StatusOK {
+		t.Errorf("bearer token should be accepted, got %d", code)
+	}
+	if code := call(nil); code != http.StatusUnauthorized {
+		t.Errorf("request without token should be rejected, got %d", code)
+	}
+	legacy := &tincweb.TincWebClient{BaseURL: ts.server.URL + "/api/" + token}
+	if _, err := legacy.Networks(ctx); err == nil {
+		t.Error("token in path should not be accepted")
+	}
+
+	login := func(code string) *http.Response {
+		res, err := http.Post(ts.server.URL+"/auth/login", "application/json", strings.NewReader(`{"code":"`+code+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	code, err := ts.ui.IssueLoginCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := login(code)
+	if res.StatusCode != http.StatusOK || len(res.Cookies()) != 1 || !res.Cookies()[0].HttpOnly {
+		t.Fatalf("login should set session cookie: %d %+v", res.StatusCode, res.Cookies())
+	}
+	session := res.Cookies()[0]
+	if res := login(code); res.StatusCode != http.StatusForbidden {
+		t.Errorf("login code should be used only once, got %d", res.StatusCode)
+	}
+	if code := call(http.Header{"Content-Type": {"application/json"}}, session); code != http.StatusOK {
+		t.Errorf("session should be accepted, got %d", code)
+	}
+	if code := call(http.Header{"Content-Type": {"text/plain"}}, session); code != http.StatusUnauthorized {
+		t.Errorf("session should be accepted only for JSON requests, got %d", code)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ts.server.URL+"/auth/logout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(session)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if code := call(http.Header{"Content-Type": {"application/json"}}, session); code != http.StatusForbidden {
+		t.Errorf("session should be revoked after logout, got %d", code)
+	}
+}
+
+func TestAPI_Socket(t *testing.T) {
+	if !web.SocketSupported {
+		t.Skip("unix socket API is not supported")
+	}
+	ts := newTestServer(t, web.Config{AuthorizedOnly: true, AuthKey: "secret"})
+	defer ts.Close()
+	socket := filepath.Join(ts.dir, "api.sock")
+	listener, err := web.ListenSocket(socket, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: ts.server.Config.Handler, ConnContext: web.ConnContext}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0660 {
+		t.Errorf("socket should be accessible only by owner and group: %v %v", info.Mode(), err)
+	}
+	if _, err := web.ListenSocket(socket, -1); err == nil {
+		t.Error("socket in use should not be replaced")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}}
+	res, err := client.Post("http://unix/rpc", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"TincWeb.Create","params":["alpha","10.155.0.0/16"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply jsonrpc2.Response
+	err = json.NewDecoder(res.Body).Decode(&reply)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tests are run by owner of the server process
+	if res.StatusCode != http.StatusOK || reply.Error != nil {
+		t.Errorf("owner of daemon should be admin over socket: %d %v", res.StatusCode, reply.Error)
+	}
+}
+
+func isForbidden(err error) bool {
+	var rpcErr *jsonrpc2.Error
+	return errors.As(err, &rpcErr) && rpcErr.Code == web.ForbiddenError
+}
+
+func TestAPI_Templates(t *testing.T) {
+	ts := newTestServer(t, web.Config{})
+	defer ts.Close()
+	ctx := context.Background()
+
+	if _, err := ts.client.SaveTemplate(ctx, shared.Template{Name: "office", Mode: "bridge"}); err == nil {
+		t.Error("invalid template should not be saved")
+	}
+	saved, err := ts.client.SaveTemplate(ctx, shared.Template{
+		Name:       "office",
+		Subnet:     "10.200.0.0/16",
+		Mode:       "router",
+		DeviceType: "tun",
+		MinPort:    30100,
+		MaxPort:    30110,
+		Scripts:    map[string]string{"host-up": "#!/bin/sh\n"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "office" {
+		t.Errorf("unexpected template: %+v", saved)
+	}
+	list, err := ts.client.Templates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("unexpected templates: %+v", list)
+	}
+
+	created, err := ts.client.CreateFromTemplate(ctx, "alpha", "", "office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ts.client.Network(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.Mode != "router" || info.Config.DeviceType != "tun" || info.Config.Port < 30100 || info.Config.Port > 30110 {
+		t.Errorf("template is not applied: %+v", info.Config)
+	}
+	self, err := ts.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Subnet != "10.200.0.0/16" || self.Port != info.Config.Port {
+		t.Errorf("unexpected self node: %+v", self)
+	}
+	if _, err := ts.client.CreateFromTemplate(ctx, "alpha", "", "office"); err == nil {
+		t.Error("template should not be applied to existent network")
+	}
+
+	removed, err := ts.client.RemoveTemplate(ctx, "office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !removed {
+		t.Error("template should be removed")
+	}
+	if _, err := ts.client.CreateFromTemplate(ctx, "beta", "", "office"); err == nil {
+		t.Error("create with unknown template should fail")
+	}
+}
+
+func TestAPI_BackupRestore(t *testing.T) {
+	source := newTestServer(t, web.Config{})
+	defer source.Close()
+	target := newTestServer(t, web.Config{})
+	defer target.Close()
+	ctx := context.Background()
+
+	if _, err := source.client.Create(ctx, "alpha", "10.155.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.client.EnableAutostart(ctx, "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := source.client.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.Manifest.Networks) != 1 || len(backup.Archive) == 0 {
+		t.Fatalf("unexpected backup: %+v", backup.Manifest)
+	}
+
+	res, err := target.client.RestoreBackup(ctx, backup.Archive, shared.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Restored) != 1 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	info, err := target.client.Network(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Autostart {
+		t.Error("autostart should be restored")
+	}
+	sourceNode, err := source.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetNode, err := target.client.Node(ctx, "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sourceNode.Name != targetNode.Name || sourceNode.PublicKey != targetNode.PublicKey {
+		t.Error("self node should be restored")
+	}
+}
@@ -0,0 +1,48 @@
+// Patch generated Go clients to use custom HTTP client instead of http.DefaultClient.
+// Usage: clientpatch <client.go>...
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	originalImport = `client "github.com/reddec/jsonrpc2/client"`
+	patchedImport  = `client "tinc-web-boot/support/go/internal/client"` + "\n\t\"net/http\""
+	originalField  = "BaseURL  string\n"
+	patchedField   = "BaseURL  string\n\tHTTP     *http.Client // client for requests (nil - http.DefaultClient)\n"
+	originalCall   = "client.CallHTTP(ctx, impl.BaseURL,"
+	patchedCall    = "client.CallHTTP(ctx, impl.HTTP, impl.BaseURL,"
+)
+
+func main() {
+	for _, file := range os.Args[1:] {
+		if err := patch(file); err != nil {
+			fmt.Fprintln(os.Stderr, file+":", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func patch(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(data, []byte(originalImport)) {
+		// already patched
+		return nil
+	}
+	data = bytes.Replace(data, []byte(originalImport), []byte(patchedImport), 1)
+	data = bytes.Replace(data, []byte(originalField), []byte(patchedField), 1)
+	data = bytes.ReplaceAll(data, []byte(originalCall), []byte(patchedCall))
+	data, err = format.Source(data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
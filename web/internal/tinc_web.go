@@ -59,6 +59,59 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Create(ctx, args.Arg0, args.Arg1)
 	})
 
+	router.RegisterFunc("TincWeb.CreateFromTemplate", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"name"`
+			Arg1 string `json:"subnet"`
+			Arg2 string `json:"template"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1, &args.Arg2)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.CreateFromTemplate(ctx, args.Arg0, args.Arg1, args.Arg2)
+	})
+
+	router.RegisterFunc("TincWeb.Rename", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"newName"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Rename(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.Clone", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"source"`
+			Arg1 string `json:"network"`
+			Arg2 string `json:"subnet"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1, &args.Arg2)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Clone(ctx, args.Arg0, args.Arg1, args.Arg2)
+	})
+
 	router.RegisterFunc("TincWeb.Remove", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"network"`
@@ -75,6 +128,129 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Remove(ctx, args.Arg0)
 	})
 
+	router.RegisterFunc("TincWeb.Templates", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Templates(ctx)
+	})
+
+	router.RegisterFunc("TincWeb.Template", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"name"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Template(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.SaveTemplate", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 shared.Template `json:"template"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.SaveTemplate(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.RemoveTemplate", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"name"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RemoveTemplate(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.Trash", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Trash(ctx)
+	})
+
+	router.RegisterFunc("TincWeb.Restore", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Restore(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.Backup", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Backup(ctx)
+	})
+
+	router.RegisterFunc("TincWeb.RestoreBackup", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 []byte                `json:"archive"`
+			Arg1 shared.RestoreOptions `json:"options"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RestoreBackup(ctx, args.Arg0, args.Arg1)
+	})
+
 	router.RegisterFunc("TincWeb.Start", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"network"`
@@ -107,6 +283,22 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Stop(ctx, args.Arg0)
 	})
 
+	router.RegisterFunc("TincWeb.Status", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Status(ctx, args.Arg0)
+	})
+
 	router.RegisterFunc("TincWeb.Peers", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"network"`
@@ -156,6 +348,23 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Import(ctx, args.Arg0)
 	})
 
+	router.RegisterFunc("TincWeb.ImportSigned", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 shared.Sharing       `json:"sharing"`
+			Arg1 shared.ImportOptions `json:"options"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.ImportSigned(ctx, args.Arg0, args.Arg1)
+	})
+
 	router.RegisterFunc("TincWeb.Share", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"network"`
@@ -172,6 +381,23 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Share(ctx, args.Arg0)
 	})
 
+	router.RegisterFunc("TincWeb.ShareEncrypted", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string              `json:"network"`
+			Arg1 shared.ShareOptions `json:"options"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.ShareEncrypted(ctx, args.Arg0, args.Arg1)
+	})
+
 	router.RegisterFunc("TincWeb.Node", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"network"`
@@ -222,6 +448,123 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Majordomo(ctx, args.Arg0, args.Arg1)
 	})
 
+	router.RegisterFunc("TincWeb.Invite", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string               `json:"network"`
+			Arg1 shared.InviteOptions `json:"options"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Invite(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.Invitations", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Invitations(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.Invitation", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Invitation(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.RevokeInvitation", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RevokeInvitation(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.JoinRequests", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.JoinRequests(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.ApproveJoin", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.ApproveJoin(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.RejectJoin", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RejectJoin(ctx, args.Arg0, args.Arg1)
+	})
+
 	router.RegisterFunc("TincWeb.Join", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"url"`
@@ -239,5 +582,135 @@ func RegisterTincWeb(router *jsonrpc2.Router, wrap shared.TincWeb) []string {
 		return wrap.Join(ctx, args.Arg0, args.Arg1)
 	})
 
-	return []string{"TincWeb.Networks", "TincWeb.Network", "TincWeb.Create", "TincWeb.Remove", "TincWeb.Start", "TincWeb.Stop", "TincWeb.Peers", "TincWeb.Peer", "TincWeb.Import", "TincWeb.Share", "TincWeb.Node", "TincWeb.Upgrade", "TincWeb.Majordomo", "TincWeb.Join"}
+	router.RegisterFunc("TincWeb.EnableAutostart", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.EnableAutostart(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.DisableAutostart", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.DisableAutostart(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.AutostartNetworks", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.AutostartNetworks(ctx)
+	})
+
+	router.RegisterFunc("TincWeb.RestartPolicy", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RestartPolicy(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.SetRestartPolicy", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string               `json:"network"`
+			Arg1 shared.RestartPolicy `json:"policy"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.SetRestartPolicy(ctx, args.Arg0, args.Arg1)
+	})
+
+	router.RegisterFunc("TincWeb.Leases", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Leases(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWeb.SetLease", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"node"`
+			Arg2 string `json:"ip"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1, &args.Arg2)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.SetLease(ctx, args.Arg0, args.Arg1, args.Arg2)
+	})
+
+	router.RegisterFunc("TincWeb.RemoveLease", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+			Arg1 string `json:"node"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RemoveLease(ctx, args.Arg0, args.Arg1)
+	})
+
+	return []string{"TincWeb.Networks", "TincWeb.Network", "TincWeb.Create", "TincWeb.CreateFromTemplate", "TincWeb.Rename", "TincWeb.Clone", "TincWeb.Remove", "TincWeb.Templates", "TincWeb.Template", "TincWeb.SaveTemplate", "TincWeb.RemoveTemplate", "TincWeb.Trash", "TincWeb.Restore", "TincWeb.Backup", "TincWeb.RestoreBackup", "TincWeb.Start", "TincWeb.Stop", "TincWeb.Status", "TincWeb.Peers", "TincWeb.Peer", "TincWeb.Import", "TincWeb.ImportSigned", "TincWeb.Share", "TincWeb.ShareEncrypted", "TincWeb.Node", "TincWeb.Upgrade", "TincWeb.Majordomo", "TincWeb.Invite", "TincWeb.Invitations", "TincWeb.Invitation", "TincWeb.RevokeInvitation", "TincWeb.JoinRequests", "TincWeb.ApproveJoin", "TincWeb.RejectJoin", "TincWeb.Join", "TincWeb.EnableAutostart", "TincWeb.DisableAutostart", "TincWeb.AutostartNetworks", "TincWeb.RestartPolicy", "TincWeb.SetRestartPolicy", "TincWeb.Leases", "TincWeb.SetLease", "TincWeb.RemoveLease"}
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	jsonrpc2 "github.com/reddec/jsonrpc2"
+	"time"
 	shared "tinc-web-boot/web/shared"
 )
 
@@ -25,6 +26,84 @@ func RegisterTincWebUI(router *jsonrpc2.Router, wrap shared.TincWebUI) []string
 		return wrap.IssueAccessToken(ctx, args.Arg0)
 	})
 
+	router.RegisterFunc("TincWebUI.IssueToken", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 shared.Role `json:"role"`
+			Arg1 string      `json:"label"`
+			Arg2 uint        `json:"validDays"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0, &args.Arg1, &args.Arg2)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.IssueToken(ctx, args.Arg0, args.Arg1, args.Arg2)
+	})
+
+	router.RegisterFunc("TincWebUI.ListTokens", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.ListTokens(ctx)
+	})
+
+	router.RegisterFunc("TincWebUI.RevokeToken", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"id"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RevokeToken(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("TincWebUI.SigningKeys", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.SigningKeys(ctx)
+	})
+
+	router.RegisterFunc("TincWebUI.RotateKey", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 time.Duration `json:"grace"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.RotateKey(ctx, args.Arg0)
+	})
+
 	router.RegisterFunc("TincWebUI.Notify", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct {
 			Arg0 string `json:"title"`
@@ -70,5 +149,19 @@ func RegisterTincWebUI(router *jsonrpc2.Router, wrap shared.TincWebUI) []string
 		return wrap.Configuration(ctx)
 	})
 
-	return []string{"TincWebUI.IssueAccessToken", "TincWebUI.Notify", "TincWebUI.Endpoints", "TincWebUI.Configuration"}
+	router.RegisterFunc("TincWebUI.Binaries", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Binaries(ctx)
+	})
+
+	return []string{"TincWebUI.IssueAccessToken", "TincWebUI.IssueToken", "TincWebUI.ListTokens", "TincWebUI.RevokeToken", "TincWebUI.SigningKeys", "TincWebUI.RotateKey", "TincWebUI.Notify", "TincWebUI.Endpoints", "TincWebUI.Configuration", "TincWebUI.Binaries"}
 }
@@ -0,0 +1,4 @@
+package internal
+
+// generated Go clients always use http.DefaultClient, patch them to accept custom client (runs after jsonrpc2-gen)
+//go:generate go run ./clientpatch ../../support/go/tincweb/client.go ../../support/go/tincwebui/client.go ../../support/go/tincwebmajordomo/client.go
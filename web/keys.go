@@ -0,0 +1,159 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"tinc-web-boot/web/shared"
+)
+
+const (
+	keySize   = 32 // bytes of HMAC secret
+	keyIDSize = 8  // bytes of key ID
+)
+
+type signingKey struct {
+	shared.SigningKey
+	Secret []byte `json:"secret"`
+}
+
+// Keys for signing tokens. Keyring is either kept in file (generated on first use, could be rotated) or
+// static (defined by configuration)
+type Keyring struct {
+	file string
+	lock sync.RWMutex
+	keys []*signingKey // first - current
+}
+
+// Load keyring from file or generate new one. File is accessible only by owner
+func LoadKeyring(file string) (*Keyring, error) {
+	kr := &Keyring{file: file}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return kr, kr.Rotate(0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &kr.keys); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	if len(kr.keys) == 0 {
+		return kr, kr.Rotate(0)
+	}
+	if info, err := os.Stat(file); err == nil && info.Mode().Perm()&0077 != 0 {
+		log.Println("[WARN]", "signing keys file", file, "is accessible by others, restricting permissions")
+		if err := os.Chmod(file, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return kr, nil
+}
+
+// Keyring with one static key without ID. Empty secret means random key (valid till restart)
+func StaticKeyring(secret string) *Keyring {
+	if secret == "" {
+		key, err := generateKey()
+		if err != nil {
+			panic(err) // crypto RNG is broken
+		}
+		return &Keyring{keys: []*signingKey{key}}
+	}
+	return &Keyring{keys: []*signingKey{{
+		SigningKey: shared.SigningKey{Created: time.Now()},
+		Secret:     []byte(secret),
+	}}}
+}
+
+// Current key for signing new tokens
+func (kr *Keyring) Current() (id string, secret []byte) {
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+	key := kr.keys[0]
+	return key.ID, key.Secret
+}
+
+// Secret of active key by ID
+func (kr *Keyring) Find(id string) ([]byte, error) {
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+	for _, key := range kr.keys {
+		if key.ID == id && key.active(time.Now()) {
+			return key.Secret, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %q is unknown or expired", id)
+}
+
+// Active keys, first one is current
+func (kr *Keyring) Keys() []*shared.SigningKey {
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+	var ans []*shared.SigningKey
+	now := time.Now()
+	for _, key := range kr.keys {
+		if key.active(now) {
+			info := key.SigningKey
+			ans = append(ans, &info)
+		}
+	}
+	return ans
+}
+
+// Generate new current key. Previous keys will be valid for verification during grace period
+func (kr *Keyring) Rotate(grace time.Duration) error {
+	if kr.file == "" {
+		return fmt.Errorf("signing key is defined by configuration and could not be rotated")
+	}
+	key, err := generateKey()
+	if err != nil {
+		return err
+	}
+	kr.lock.Lock()
+	defer kr.lock.Unlock()
+	now := time.Now()
+	var keys = []*signingKey{key}
+	for _, prev := range kr.keys {
+		old := *prev
+		if old.Expires.IsZero() || old.Expires.After(now.Add(grace)) {
+			old.Expires = now.Add(grace)
+		}
+		if old.active(now) {
+			keys = append(keys, &old)
+		}
+	}
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(kr.file, data, 0600); err != nil {
+		return err
+	}
+	kr.keys = keys
+	return nil
+}
+
+func (key *signingKey) active(now time.Time) bool {
+	return key.Expires.IsZero() || now.Before(key.Expires)
+}
+
+func generateKey() (*signingKey, error) {
+	var id = make([]byte, keyIDSize)
+	var secret = make([]byte, keySize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		SigningKey: shared.SigningKey{ID: hex.EncodeToString(id), Created: time.Now()},
+		Secret:     secret,
+	}, nil
+}
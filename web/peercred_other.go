@@ -0,0 +1,16 @@
+// +build !linux
+
+package web
+
+import (
+	"fmt"
+	"net"
+)
+
+// Unix socket API with peer credentials is supported
+const SocketSupported = false
+
+// peer credentials are supported only on Linux
+func peerCredentials(conn net.Conn) (*PeerCredentials, error) {
+	return nil, fmt.Errorf("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/reddec/jsonrpc2"
+	"tinc-web-boot/web/shared"
+)
+
+// JSON-RPC error code for calls rejected by role check (same as HTTP status)
+const ForbiddenError = 403
+
+// Minimal role required to call API method. Methods which are not listed are allowed only to admin
+var methodRoles = map[string]shared.Role{
+	"TincWeb.Networks":           shared.RoleViewer,
+	"TincWeb.Network":            shared.RoleViewer,
+	"TincWeb.Templates":          shared.RoleViewer,
+	"TincWeb.Template":           shared.RoleViewer,
+	"TincWeb.Trash":              shared.RoleViewer,
+	"TincWeb.Status":             shared.RoleViewer,
+	"TincWeb.Peers":              shared.RoleViewer,
+	"TincWeb.Peer":               shared.RoleViewer,
+	"TincWeb.Share":              shared.RoleViewer,
+	"TincWeb.ShareEncrypted":     shared.RoleViewer,
+	"TincWeb.Node":               shared.RoleViewer,
+	"TincWeb.AutostartNetworks":  shared.RoleViewer,
+	"TincWeb.RestartPolicy":      shared.RoleViewer,
+	"TincWeb.Leases":             shared.RoleViewer,
+	"TincWeb.Invitations":        shared.RoleViewer,
+	"TincWeb.Invitation":         shared.RoleViewer,
+	"TincWeb.JoinRequests":       shared.RoleViewer,
+	"TincWeb.Create":             shared.RoleOperator,
+	"TincWeb.CreateFromTemplate": shared.RoleOperator,
+	"TincWeb.Rename":             shared.RoleOperator,
+	"TincWeb.Clone":              shared.RoleOperator,
+	"TincWeb.Restore":            shared.RoleOperator,
+	"TincWeb.Start":              shared.RoleOperator,
+	"TincWeb.Stop":               shared.RoleOperator,
+	"TincWeb.Import":             shared.RoleOperator,
+	"TincWeb.ImportSigned":       shared.RoleOperator,
+	"TincWeb.Upgrade":            shared.RoleOperator,
+	"TincWeb.Majordomo":          shared.RoleOperator,
+	"TincWeb.Invite":             shared.RoleOperator,
+	"TincWeb.RevokeInvitation":   shared.RoleOperator,
+	"TincWeb.Join":               shared.RoleOperator,
+	"TincWeb.EnableAutostart":    shared.RoleOperator,
+	"TincWeb.DisableAutostart":   shared.RoleOperator,
+	"TincWeb.SetRestartPolicy":   shared.RoleOperator,
+	"TincWeb.SetLease":           shared.RoleOperator,
+	"TincWeb.RemoveLease":        shared.RoleOperator,
+	"TincWeb.Remove":             shared.RoleAdmin,
+	"TincWeb.SaveTemplate":       shared.RoleAdmin,
+	"TincWeb.RemoveTemplate":     shared.RoleAdmin,
+	"TincWeb.Backup":             shared.RoleAdmin, // archive contains private keys
+	"TincWeb.RestoreBackup":      shared.RoleAdmin,
+	"TincWeb.ApproveJoin":        shared.RoleAdmin,
+	"TincWeb.RejectJoin":         shared.RoleAdmin,
+	"TincWebUI.Notify":           shared.RoleOperator,
+	"TincWebUI.Endpoints":        shared.RoleViewer,
+	"TincWebUI.Configuration":    shared.RoleViewer,
+	"TincWebUI.Binaries":         shared.RoleViewer,
+	"TincWebUI.IssueAccessToken": shared.RoleAdmin,
+	"TincWebUI.IssueToken":       shared.RoleAdmin,
+	"TincWebUI.ListTokens":       shared.RoleAdmin,
+	"TincWebUI.RevokeToken":      shared.RoleAdmin,
+	"TincWebUI.SigningKeys":      shared.RoleAdmin,
+	"TincWebUI.RotateKey":        shared.RoleAdmin,
+	"TincWebMajordomo.Join":      shared.RoleMajordomo,
+}
+
+// privileges of roles: role is allowed to do everything allowed to roles with lower level
+var roleLevels = map[shared.Role]int{
+	shared.RoleViewer:   1,
+	shared.RoleOperator: 2,
+	shared.RoleAdmin:    3,
+}
+
+// check that role could act as required role. Majordomo is not comparable with other roles
+func roleAllows(role, required shared.Role) bool {
+	if role == shared.RoleMajordomo || required == shared.RoleMajordomo {
+		return role == required
+	}
+	level, known := roleLevels[role]
+	return known && level >= roleLevels[required]
+}
+
+func isValidRole(role shared.Role) bool {
+	_, ok := roleLevels[role]
+	return ok || role == shared.RoleMajordomo
+}
+
+type roleKey struct{}
+
+type callerKey struct{}
+
+// details about client available for handlers
+type caller struct {
+	Token   *shared.Token // token used for authorization
+	Claims  jwt.MapClaims // claims of token
+	Address string        // remote IP
+}
+
+func withCaller(ctx context.Context, info *caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, info)
+}
+
+func callerFromContext(ctx context.Context) (*caller, bool) {
+	info, ok := ctx.Value(callerKey{}).(*caller)
+	return info, ok
+}
+
+func withRole(ctx context.Context, role shared.Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// role of authorized client (empty if request is not authorized)
+func roleFromContext(ctx context.Context) shared.Role {
+	role, _ := ctx.Value(roleKey{}).(shared.Role)
+	return role
+}
+
+// method interceptor which rejects calls not allowed for role of client
+func checkRole(ic *jsonrpc2.MethodInterceptorContext) (interface{}, error) {
+	role := roleFromContext(ic.Context)
+	required, ok := methodRoles[ic.Request.Method]
+	if !ok {
+		required = shared.RoleAdmin
+	}
+	if !roleAllows(role, required) {
+		return nil, &jsonrpc2.Error{
+			Code:    ForbiddenError,
+			Message: fmt.Sprintf("forbidden: method %s requires role %s", ic.Request.Method, required),
+			Data:    map[string]string{"role": string(role), "required": string(required)},
+		}
+	}
+	return ic.Next()
+}
@@ -1,9 +1,12 @@
 package web
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -22,13 +25,18 @@ import (
 )
 
 const (
-	joinTimeout = 15 * time.Second
+	joinTimeout      = 15 * time.Second
+	joinPollInterval = 2 * time.Second // interval of join requests while waiting for approval
 )
 
 type Config struct {
 	Dev             bool
 	AuthorizedOnly  bool
-	AuthKey         string
+	AuthKey         string   // static signing key (used if keyring not defined, empty - random)
+	Keyring         *Keyring // signing keys
+	TokensFile      string   // file for registry of issued tokens (empty - kept in memory)
+	NoTokenPath     bool     // do not serve legacy API with token in URL path (/api/:token/)
+	SocketGroups    []int    // members of groups (primary or supplementary) are admins over unix socket
 	LocalUIPort     uint16
 	PublicAddresses []string
 	Binding         string
@@ -37,7 +45,8 @@ type Config struct {
 //go:generate go-bindata -pkg web -prefix ui/build/ -fs ui/build/...
 func (cfg Config) New(pool *pool.Pool) (*gin.Engine, *uiRoutes) {
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.LoggerWithFormatter(logFormatter), gin.Recovery())
 
 	if cfg.Dev {
 		router.Use(func(gctx *gin.Context) {
@@ -65,33 +74,54 @@ func (cfg Config) New(pool *pool.Pool) (*gin.Engine, *uiRoutes) {
 	}
 
 	var jsonRouter jsonrpc2.Router
+	keys := cfg.Keyring
+	if keys == nil {
+		keys = StaticKeyring(cfg.AuthKey)
+	}
+	tokens := newTokenRegistry(keys, cfg.TokensFile)
 	uiApp := &uiRoutes{
-		key:           cfg.AuthKey,
+		tokens:        tokens,
+		codes:         &loginCodes{},
 		port:          cfg.LocalUIPort,
 		publicAddress: cfg.PublicAddresses,
 		pool:          pool,
 		config:        shared.Config{Binding: cfg.Binding},
 	}
 
-	internal.RegisterTincWeb(&jsonRouter, &api{pool: pool, publicAddress: cfg.PublicAddresses, key: cfg.AuthKey})
+	internal.RegisterTincWeb(&jsonRouter, &api{pool: pool, publicAddress: cfg.PublicAddresses, tokens: tokens})
 	internal.RegisterTincWebUI(&jsonRouter, uiApp)
+	jsonRouter.InterceptMethods(checkRole)
 
 	streamer := events.NewWebsocketStream()
 	pool.Events().Sink(streamer.Feed)
+	pool.Lifecycle().Sink(streamer.Feed)
 
 	router.StaticFS("/static", AssetFile())
 
 	var majordomoRouter jsonrpc2.Router
 	internal.RegisterTincWebMajordomo(&majordomoRouter, NewMajordomo(pool))
+	majordomoRouter.InterceptMethods(checkRole)
+
+	majordomo := router.Group("/majordomo/:token", cfg.majordomoOnly(tokens))
+	majordomo.POST("", handleRPC(&majordomoRouter))
 
-	majordomo := router.Group("/majordomo/:token", cfg.majordomoOnly())
-	majordomo.POST("", gin.WrapH(jsonrpc2.HandlerRest(&majordomoRouter)))
+	auth := router.Group("/auth")
+	auth.POST("login", uiApp.login)
+	auth.POST("logout", uiApp.logout)
+	auth.POST("code", uiApp.sendLoginCode)
 
-	api := router.Group("/api/:token/", cfg.authorizedOnly())
+	rpc := router.Group("/rpc", cfg.authorizedOnly(tokens, requestToken))
+	rpc.POST("", handleRPC(&jsonRouter))
+	rpc.GET("", handleRPC(&jsonRouter))
+	rpc.GET("events", requireRole(shared.RoleViewer), gin.WrapH(streamer))
 
-	api.POST("", gin.WrapH(jsonrpc2.HandlerRest(&jsonRouter)))
-	api.GET("", gin.WrapH(jsonrpc2.HandlerWS(&jsonRouter)))
-	api.GET("events", gin.WrapH(streamer))
+	if !cfg.NoTokenPath {
+		// for older clients: token is part of URL
+		api := router.Group("/api/:token/", cfg.authorizedOnly(tokens, pathToken))
+		api.POST("", handleRPC(&jsonRouter))
+		api.GET("", handleRPC(&jsonRouter))
+		api.GET("events", requireRole(shared.RoleViewer), gin.WrapH(streamer))
+	}
 
 	router.GET("/", func(gctx *gin.Context) {
 		gctx.Redirect(http.StatusTemporaryRedirect, "/static")
@@ -99,62 +129,101 @@ func (cfg Config) New(pool *pool.Pool) (*gin.Engine, *uiRoutes) {
 	return router, uiApp
 }
 
-func (cfg Config) authorizedOnly() gin.HandlerFunc {
+// serve JSON-RPC requests over HTTP (POST) or websocket (GET). Role of client is passed to methods in context
+func handleRPC(router *jsonrpc2.Router) gin.HandlerFunc {
+	return func(gctx *gin.Context) {
+		ctx := gctx.Request.Context()
+		if gctx.Request.Method == http.MethodGet {
+			jsonrpc2.HandlerWSContext(ctx, router).ServeHTTP(gctx.Writer, gctx.Request)
+		} else {
+			jsonrpc2.HandlerRestContext(ctx, router).ServeHTTP(gctx.Writer, gctx.Request)
+		}
+	}
+}
+
+func requireRole(required shared.Role) gin.HandlerFunc {
+	return func(gctx *gin.Context) {
+		if role := roleFromContext(gctx.Request.Context()); !roleAllows(role, required) {
+			log.Println("[guard]", "role", role, "is not allowed to", gctx.Request.URL.Path)
+			gctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		gctx.Next()
+	}
+}
+
+func (cfg Config) authorizedOnly(tokens *tokenRegistry, extract func(gctx *gin.Context) (string, error)) gin.HandlerFunc {
 	return func(gctx *gin.Context) {
+		if creds, ok := peerFromContext(gctx.Request.Context()); ok {
+			// local process over unix socket
+			role, err := cfg.peerRole(creds)
+			if err != nil {
+				log.Println("[guard]", "check peer failed:", err)
+				gctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			gctx.Request = gctx.Request.WithContext(withRole(gctx.Request.Context(), role))
+			gctx.Next()
+			return
+		}
 		host, _, _ := net.SplitHostPort(gctx.Request.RemoteAddr)
 		if host == "127.0.0.1" && !cfg.AuthorizedOnly {
 			// assume localhost connection are authorized
+			gctx.Request = gctx.Request.WithContext(withRole(gctx.Request.Context(), shared.RoleAdmin))
 			gctx.Next()
 			return
 		}
-		token := gctx.Param("token")
-		_, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(cfg.AuthKey), nil
-		})
+		token, err := extract(gctx)
+		if err != nil {
+			log.Println("[guard]", "get token failed:", err)
+			gctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		_, info, err := tokens.Check(token)
 		if err != nil {
 			log.Println("[guard]", "check token failed:", err)
 			gctx.AbortWithStatus(http.StatusForbidden)
 			return
 		}
+		role := info.Role
+		if role == shared.RoleMajordomo {
+			log.Println("[guard]", "majordomo token used for API")
+			gctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		gctx.Request = gctx.Request.WithContext(withRole(gctx.Request.Context(), role))
 		gctx.Next()
 	}
 }
 
-func (cfg Config) majordomoOnly() gin.HandlerFunc {
+func pathToken(gctx *gin.Context) (string, error) {
+	return gctx.Param("token"), nil
+}
+
+func (cfg Config) majordomoOnly(tokens *tokenRegistry) gin.HandlerFunc {
 	return func(gctx *gin.Context) {
-		token := gctx.Param("token")
-		claims, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(cfg.AuthKey), nil
-		})
+		claims, info, err := tokens.Check(gctx.Param("token"))
 		if err != nil {
 			log.Println("[guard]", "check token failed:", err)
 			gctx.AbortWithStatus(http.StatusForbidden)
 			return
 		}
-		mp, ok := claims.Claims.(jwt.MapClaims)
-		if !ok {
-			log.Println("[guard]", "claims not a map")
-			gctx.AbortWithStatus(http.StatusForbidden)
-			return
-		}
-		if v, ok := mp["role"].(string); !ok || v != "majordomo" {
+		role := info.Role
+		if role != shared.RoleMajordomo {
 			log.Println("[guard]", "wrong role")
 			gctx.AbortWithStatus(http.StatusForbidden)
 			return
 		}
+		ctx := withRole(gctx.Request.Context(), role)
+		ctx = withCaller(ctx, &caller{Token: info, Claims: claims, Address: gctx.ClientIP()})
+		gctx.Request = gctx.Request.WithContext(ctx)
 		gctx.Next()
 	}
 }
 
 type api struct {
 	pool          *pool.Pool
-	key           string
+	tokens        *tokenRegistry
 	publicAddress []string
 }
 
@@ -166,8 +235,9 @@ func (srv *api) Networks(ctx context.Context) ([]*shared.Network, error) {
 	var ans []*shared.Network
 	for _, ntw := range list {
 		ans = append(ans, &shared.Network{
-			Name:    ntw.Name(),
-			Running: srv.pool.IsRunning(ntw.Name()),
+			Name:      ntw.Name(),
+			Running:   srv.pool.IsRunning(ntw.Name()),
+			Autostart: srv.pool.IsAutoStart(ntw.Name()),
 		})
 	}
 	return ans, nil
@@ -183,9 +253,10 @@ func (srv *api) Network(ctx context.Context, name string) (*shared.Network, erro
 		return nil, err
 	}
 	return &shared.Network{
-		Name:    ntw.Name(),
-		Running: srv.pool.IsRunning(ntw.Name()),
-		Config:  config,
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
+		Config:    config,
 	}, nil
 }
 
@@ -232,17 +303,66 @@ func (srv *api) Peer(ctx context.Context, network, name string) (*shared.PeerInf
 }
 
 func (srv *api) Create(ctx context.Context, name, subnet string) (*shared.Network, error) {
-	_, cidr, err := net.ParseCIDR(subnet)
+	return srv.CreateFromTemplate(ctx, name, subnet, "")
+}
+
+func (srv *api) CreateFromTemplate(ctx context.Context, name, subnet, template string) (*shared.Network, error) {
+	var cidr *net.IPNet
+	if subnet != "" {
+		_, parsed, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("parse subnet: %w", err)
+		}
+		cidr = parsed
+	}
+	var (
+		ntw *network.Network
+		err error
+	)
+	if template != "" {
+		ntw, err = srv.pool.CreateFromTemplate(name, cidr, template)
+	} else {
+		ntw, err = srv.pool.Create(name, cidr)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("parse subnet: %w", err)
+		return nil, err
+	}
+	return &shared.Network{
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
+	}, nil
+}
+
+func (srv *api) Rename(ctx context.Context, network, newName string) (*shared.Network, error) {
+	ntw, err := srv.pool.Rename(network, newName)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.Network{
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
+	}, nil
+}
+
+func (srv *api) Clone(ctx context.Context, source, network, subnet string) (*shared.Network, error) {
+	var cidr *net.IPNet
+	if subnet != "" {
+		_, parsed, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, fmt.Errorf("parse subnet: %w", err)
+		}
+		cidr = parsed
 	}
-	ntw, err := srv.pool.Create(name, cidr)
+	ntw, err := srv.pool.Clone(source, network, cidr)
 	if err != nil {
 		return nil, err
 	}
 	return &shared.Network{
-		Name:    ntw.Name(),
-		Running: srv.pool.IsRunning(ntw.Name()),
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
 	}, nil
 }
 
@@ -251,6 +371,77 @@ func (srv *api) Remove(ctx context.Context, network string) (bool, error) {
 	return exists, err
 }
 
+func (srv *api) Templates(ctx context.Context) ([]*shared.Template, error) {
+	list := srv.pool.Templates()
+	var ans = make([]*shared.Template, 0, len(list))
+	for _, tpl := range list {
+		ans = append(ans, toTemplate(tpl))
+	}
+	return ans, nil
+}
+
+func (srv *api) Template(ctx context.Context, name string) (*shared.Template, error) {
+	tpl, err := srv.pool.Template(name)
+	if err != nil {
+		return nil, err
+	}
+	return toTemplate(tpl), nil
+}
+
+func (srv *api) SaveTemplate(ctx context.Context, template shared.Template) (*shared.Template, error) {
+	if err := srv.pool.SaveTemplate(pool.Template(template)); err != nil {
+		return nil, err
+	}
+	return srv.Template(ctx, template.Name)
+}
+
+func (srv *api) RemoveTemplate(ctx context.Context, name string) (bool, error) {
+	return srv.pool.RemoveTemplate(name)
+}
+
+func (srv *api) Trash(ctx context.Context) ([]*shared.TrashItem, error) {
+	items, err := srv.pool.Trash()
+	if err != nil {
+		return nil, err
+	}
+	var ans = make([]*shared.TrashItem, 0, len(items))
+	for _, item := range items {
+		dto := shared.TrashItem(*item)
+		ans = append(ans, &dto)
+	}
+	return ans, nil
+}
+
+func (srv *api) Restore(ctx context.Context, id string) (*shared.Network, error) {
+	ntw, err := srv.pool.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.Network{
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
+	}, nil
+}
+
+func (srv *api) Backup(ctx context.Context) (*shared.Backup, error) {
+	var archive bytes.Buffer
+	manifest, err := srv.pool.Backup(&archive)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.Backup{Manifest: toBackupManifest(manifest), Archive: archive.Bytes()}, nil
+}
+
+func (srv *api) RestoreBackup(ctx context.Context, archive []byte, options shared.RestoreOptions) (*shared.RestoreResult, error) {
+	res, err := srv.pool.RestoreBackup(bytes.NewReader(archive), pool.RestoreOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	dto := shared.RestoreResult(*res)
+	return &dto, nil
+}
+
 func (srv *api) Start(ctx context.Context, network string) (*shared.Network, error) {
 	ntw, err := srv.pool.Network(network)
 	if err != nil {
@@ -261,8 +452,9 @@ func (srv *api) Start(ctx context.Context, network string) (*shared.Network, err
 		return nil, err
 	}
 	return &shared.Network{
-		Name:    ntw.Name(),
-		Running: instance.IsRunning(),
+		Name:      ntw.Name(),
+		Running:   instance.IsRunning(),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
 	}, nil
 }
 
@@ -277,12 +469,83 @@ func (srv *api) Stop(ctx context.Context, network string) (*shared.Network, erro
 		<-instance.Done()
 	}
 	return &shared.Network{
-		Name:    ntw.Name(),
-		Running: srv.pool.IsRunning(ntw.Name()),
+		Name:      ntw.Name(),
+		Running:   srv.pool.IsRunning(ntw.Name()),
+		Autostart: srv.pool.IsAutoStart(ntw.Name()),
 	}, nil
 }
 
+func (srv *api) Status(ctx context.Context, network string) (*shared.Status, error) {
+	status, err := srv.pool.Status(network)
+	if err != nil {
+		return nil, err
+	}
+	dto := shared.Status(*status)
+	return &dto, nil
+}
+
 func (srv *api) Import(ctx context.Context, sharing shared.Sharing) (*shared.Network, error) {
+	imported, err := srv.ImportSigned(ctx, sharing, shared.ImportOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return imported.Network, nil
+}
+
+func (srv *api) ImportSigned(ctx context.Context, sharing shared.Sharing, options shared.ImportOptions) (*shared.Imported, error) {
+	verified, err := srv.verifyImport(sharing, options)
+	if err != nil {
+		return nil, err
+	}
+	return srv.importVerified(verified)
+}
+
+// sharing document checked by signature and signer
+type verifiedSharing struct {
+	sharing shared.Sharing
+	signer  string
+	trusted bool
+}
+
+// decrypt document (if required), check signature and signer. Nothing is changed in the pool
+func (srv *api) verifyImport(sharing shared.Sharing, options shared.ImportOptions) (*verifiedSharing, error) {
+	if sharing.Encrypted != nil {
+		if options.Passphrase == "" {
+			return nil, fmt.Errorf("document is encrypted, passphrase is required")
+		}
+		decrypted, err := decryptSharing(sharing.Encrypted, options.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		sharing = *decrypted
+	}
+	signer, err := verifySharing(sharing)
+	if err != nil {
+		return nil, fmt.Errorf("check signature: %w", err)
+	}
+	if options.Name != "" {
+		sharing.Name = options.Name
+	}
+	trusted, err := isTrustedSigner(srv.pool, sharing.Name, signer, options.Signer)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted && !options.Force {
+		message := "document is not signed"
+		if signer != "" {
+			message = "document is signed by untrusted key " + signer
+		}
+		return nil, &jsonrpc2.Error{
+			Code:    ForbiddenError,
+			Message: message,
+			Data:    map[string]string{"signer": signer},
+		}
+	}
+	return &verifiedSharing{sharing: sharing, signer: signer, trusted: trusted}, nil
+}
+
+func (srv *api) importVerified(verified *verifiedSharing) (*shared.Imported, error) {
+	sharing, signer := verified.sharing, verified.signer
 	_, cidr, err := net.ParseCIDR(sharing.Subnet)
 	if err != nil {
 		return nil, fmt.Errorf("parse subnet: %w", err)
@@ -298,37 +561,52 @@ func (srv *api) Import(ctx context.Context, sharing shared.Sharing) (*shared.Net
 	}
 
 	for _, node := range sharing.Nodes {
-		err := ntw.Put(node)
+		err := srv.pool.PutNode(ntw, node)
 		if err != nil {
 			return nil, fmt.Errorf("import node %s: %w", node.Name, err)
 		}
 	}
+	if signer != "" {
+		// next documents from same signer are trusted
+		if _, err := srv.pool.TrustSigner(ntw.Name(), signer); err != nil {
+			return nil, err
+		}
+	}
 
-	return &shared.Network{
-		Name:    ntw.Name(),
-		Running: srv.pool.IsRunning(ntw.Name()),
-		Config:  config,
+	return &shared.Imported{
+		Network: &shared.Network{
+			Name:      ntw.Name(),
+			Running:   srv.pool.IsRunning(ntw.Name()),
+			Autostart: srv.pool.IsAutoStart(ntw.Name()),
+			Config:    config,
+		},
+		Signer:  signer,
+		Trusted: verified.trusted,
 	}, nil
 }
 
 func (srv *api) Share(ctx context.Context, network string) (*shared.Sharing, error) {
-	ntw, err := srv.pool.Network(network)
-	if err != nil {
-		return nil, err
-	}
-	return NewShare(ntw)
+	return signedShare(srv.pool, network)
 }
 
-func (srv *api) Upgrade(ctx context.Context, network string, update network.Upgrade) (*network.Node, error) {
-	ntw, err := srv.pool.Network(network)
+func (srv *api) ShareEncrypted(ctx context.Context, network string, options shared.ShareOptions) (*shared.Sharing, error) {
+	if options.Passphrase == "" {
+		return nil, fmt.Errorf("passphrase required")
+	}
+	sharing, err := signedShare(srv.pool, network)
 	if err != nil {
 		return nil, err
 	}
-	err = ntw.Upgrade(update)
+	return encryptSharing(sharing, options.Passphrase)
+}
+
+func (srv *api) Upgrade(ctx context.Context, network string, update network.Upgrade) (*shared.UpgradeResult, error) {
+	res, err := srv.pool.Upgrade(network, update)
 	if err != nil {
 		return nil, err
 	}
-	return srv.Node(ctx, network)
+	dto := shared.UpgradeResult(*res)
+	return &dto, nil
 }
 
 func (srv *api) Node(ctx context.Context, network string) (*network.Node, error) {
@@ -344,30 +622,191 @@ func (srv *api) Node(ctx context.Context, network string) (*network.Node, error)
 }
 
 func (srv *api) Majordomo(ctx context.Context, network string, lifetime time.Duration) (string, error) {
+	invite, err := srv.Invite(ctx, network, shared.InviteOptions{Lifetime: lifetime})
+	if err != nil {
+		return "", err
+	}
+	return invite.Link, nil
+}
+
+func (srv *api) Invite(ctx context.Context, network string, options shared.InviteOptions) (*shared.Invite, error) {
 	if len(srv.publicAddress) == 0 {
-		return "", fmt.Errorf("no public addreses defined")
+		return nil, fmt.Errorf("no public addreses defined")
 	}
 	ntw, err := srv.pool.Network(network)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	self, err := ntw.Self()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	owner, err := srv.pool.OwnerKey(network)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iat":     time.Now().Add(lifetime),
-		"role":    "majordomo",
+	tok, info, err := srv.tokens.Issue(shared.RoleMajordomo, "invite to "+network, options.Lifetime, jwt.MapClaims{
 		"subnet":  self.Subnet,
 		"network": network,
+		"signer":  pool.Fingerprint(owner.Public().(ed25519.PublicKey)), // joining node trusts shared network by link
 	})
-	tok, err := token.SignedString([]byte(srv.key))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	invitation, err := srv.pool.CreateInvitation(network, pool.Invitation{
+		ID:       info.ID,
+		NodeName: options.NodeName,
+		MaxUses:  options.MaxUses,
+		Approval: options.Approval,
+		Created:  info.Issued,
+		Expires:  info.Expires,
+	})
+	if err != nil {
+		_, _ = srv.tokens.Revoke(info.ID)
+		return nil, err
+	}
+	return &shared.Invite{
+		Link:       "http://" + srv.publicAddress[0] + "/majordomo/" + tok,
+		Invitation: toInvitation(invitation),
+	}, nil
+}
+
+func (srv *api) Invitations(ctx context.Context, network string) ([]*shared.Invitation, error) {
+	list, err := srv.pool.Invitations(network)
+	if err != nil {
+		return nil, err
+	}
+	var ans = make([]*shared.Invitation, 0, len(list))
+	for _, invitation := range list {
+		ans = append(ans, toInvitation(invitation))
+	}
+	return ans, nil
+}
+
+func (srv *api) Invitation(ctx context.Context, network, id string) (*shared.Invitation, error) {
+	invitation, err := srv.pool.Invitation(network, id)
+	if err != nil {
+		return nil, err
+	}
+	return toInvitation(invitation), nil
+}
+
+func (srv *api) RevokeInvitation(ctx context.Context, network, id string) (bool, error) {
+	revoked, err := srv.pool.RevokeInvitation(network, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := srv.tokens.Revoke(id); err != nil {
+		return revoked, err
 	}
+	return revoked, nil
+}
+
+func (srv *api) JoinRequests(ctx context.Context, network string) ([]*shared.JoinRequest, error) {
+	list, err := srv.pool.JoinRequests(network)
+	if err != nil {
+		return nil, err
+	}
+	var ans = make([]*shared.JoinRequest, 0, len(list))
+	for _, request := range list {
+		ans = append(ans, toJoinRequest(request))
+	}
+	return ans, nil
+}
 
-	return "http://" + srv.publicAddress[0] + "/majordomo/" + tok, nil
+func (srv *api) ApproveJoin(ctx context.Context, network, id string) (*shared.JoinRequest, error) {
+	request, err := srv.pool.ApproveJoin(network, id)
+	if err != nil {
+		return nil, err
+	}
+	return toJoinRequest(request), nil
+}
+
+func (srv *api) RejectJoin(ctx context.Context, network, id string) (*shared.JoinRequest, error) {
+	request, err := srv.pool.RejectJoin(network, id)
+	if err != nil {
+		return nil, err
+	}
+	return toJoinRequest(request), nil
+}
+
+func (srv *api) EnableAutostart(ctx context.Context, network string) (*shared.Network, error) {
+	return srv.setAutostart(network, true)
+}
+
+func (srv *api) DisableAutostart(ctx context.Context, network string) (*shared.Network, error) {
+	return srv.setAutostart(network, false)
+}
+
+func (srv *api) AutostartNetworks(ctx context.Context) ([]string, error) {
+	return srv.pool.AutoStarts(), nil
+}
+
+func (srv *api) RestartPolicy(ctx context.Context, network string) (*shared.RestartPolicy, error) {
+	if _, err := srv.pool.Network(network); err != nil {
+		return nil, err
+	}
+	policy := srv.pool.RestartPolicy(network)
+	return &shared.RestartPolicy{
+		Mode:        shared.RestartMode(policy.Mode),
+		MinBackoff:  policy.MinBackoff,
+		MaxBackoff:  policy.MaxBackoff,
+		MaxRestarts: policy.MaxRestarts,
+		Window:      policy.Window,
+	}, nil
+}
+
+func (srv *api) SetRestartPolicy(ctx context.Context, network string, policy shared.RestartPolicy) (*shared.RestartPolicy, error) {
+	err := srv.pool.SetRestartPolicy(network, pool.RestartPolicy{
+		Mode:        pool.RestartMode(policy.Mode),
+		MinBackoff:  policy.MinBackoff,
+		MaxBackoff:  policy.MaxBackoff,
+		MaxRestarts: policy.MaxRestarts,
+		Window:      policy.Window,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return srv.RestartPolicy(ctx, network)
+}
+
+func (srv *api) Leases(ctx context.Context, network string) ([]*shared.Lease, error) {
+	list, err := srv.pool.Leases(network)
+	if err != nil {
+		return nil, err
+	}
+	var ans = make([]*shared.Lease, 0, len(list))
+	for _, lease := range list {
+		dto := shared.Lease(*lease)
+		ans = append(ans, &dto)
+	}
+	return ans, nil
+}
+
+func (srv *api) SetLease(ctx context.Context, network, node, ip string) (*shared.Lease, error) {
+	lease, err := srv.pool.SetLease(network, node, ip)
+	if err != nil {
+		return nil, err
+	}
+	dto := shared.Lease(*lease)
+	return &dto, nil
+}
+
+func (srv *api) RemoveLease(ctx context.Context, network, node string) (bool, error) {
+	return srv.pool.RemoveLease(network, node)
+}
+
+func (srv *api) setAutostart(network string, enabled bool) (*shared.Network, error) {
+	err := srv.pool.SetAutoStart(network, enabled)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.Network{
+		Name:      network,
+		Running:   srv.pool.IsRunning(network),
+		Autostart: srv.pool.IsAutoStart(network),
+	}, nil
 }
 
 func NewShare(ntw *network.Network) (*shared.Sharing, error) {
@@ -393,8 +832,11 @@ func NewShare(ntw *network.Network) (*shared.Sharing, error) {
 func (srv *api) Join(ctx context.Context, url string, start bool) (*shared.Network, error) {
 	parts := strings.Split(url, "/")
 	token := parts[len(parts)-1]
-	data := strings.Split(token, ".")[1]
-	bindata, err := base64.RawStdEncoding.DecodeString(data)
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("invalid majordomo link")
+	}
+	bindata, err := base64.RawURLEncoding.DecodeString(segments[1])
 	if err != nil {
 		return nil, err
 	}
@@ -402,6 +844,7 @@ func (srv *api) Join(ctx context.Context, url string, start bool) (*shared.Netwo
 	var share struct {
 		Network string `json:"network"`
 		Subnet  string `json:"subnet"`
+		Signer  string `json:"signer"`
 	}
 
 	err = json.Unmarshal(bindata, &share)
@@ -421,19 +864,99 @@ func (srv *api) Join(ctx context.Context, url string, start bool) (*shared.Netwo
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), joinTimeout)
-	defer cancel()
-	sharedNet, err := remote.Join(ctx, share.Network, self)
+	sharedNet, err := joinRemote(ctx, remote, share.Network, self)
+	if err != nil {
+		return nil, err
+	}
+
+	// links from previous versions have no signer and network is shared without signature
+	verified, err := srv.verifyImport(*sharedNet, shared.ImportOptions{Signer: share.Signer, Force: share.Signer == ""})
 	if err != nil {
 		return nil, err
 	}
 
-	info, err := srv.Import(ctx, *sharedNet)
+	// inviting node is address authority: self node could be re-addressed
+	for _, node := range verified.sharing.Nodes {
+		if node.Name != self.Name {
+			continue
+		}
+		if _, err := srv.pool.AcceptAssignment(ntw.Name, node); err != nil {
+			return nil, fmt.Errorf("accept assigned address: %w", err)
+		}
+	}
+
+	info, err := srv.importVerified(verified)
 	if err != nil {
 		return nil, err
 	}
 	if start {
-		return srv.Start(ctx, info.Name)
+		return srv.Start(ctx, info.Network.Name)
+	}
+	return info.Network, nil
+}
+
+// join remote network and wait for approval if required. Waiting is limited by context and invitation lifetime
+func joinRemote(ctx context.Context, remote *tincwebmajordomo.TincWebMajordomoClient, network string, self *network.Node) (*shared.Sharing, error) {
+	for {
+		callCtx, cancel := context.WithTimeout(ctx, joinTimeout)
+		sharedNet, err := remote.Join(callCtx, network, self)
+		cancel()
+		var rpcErr *jsonrpc2.Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != PendingError {
+			return sharedNet, err
+		}
+		log.Println("waiting for approval of join to", network)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("join request is not approved: %w", ctx.Err())
+		case <-time.After(joinPollInterval):
+		}
+	}
+}
+
+func toTemplate(tpl *pool.Template) *shared.Template {
+	dto := shared.Template(*tpl)
+	return &dto
+}
+
+func toBackupManifest(manifest *pool.BackupManifest) *shared.BackupManifest {
+	dto := &shared.BackupManifest{
+		Version:  manifest.Version,
+		Created:  manifest.Created,
+		Networks: make([]*shared.BackupNetwork, 0, len(manifest.Networks)),
+	}
+	for _, ntw := range manifest.Networks {
+		saved := shared.BackupNetwork(*ntw)
+		dto.Networks = append(dto.Networks, &saved)
+	}
+	return dto
+}
+
+func toInvitation(invitation *pool.Invitation) *shared.Invitation {
+	dto := &shared.Invitation{
+		ID:       invitation.ID,
+		NodeName: invitation.NodeName,
+		MaxUses:  invitation.MaxUses,
+		Approval: invitation.Approval,
+		Created:  invitation.Created,
+		Expires:  invitation.Expires,
+		Revoked:  invitation.Revoked,
+	}
+	for _, redemption := range invitation.Redemptions {
+		join := shared.Redemption(*redemption)
+		dto.Redemptions = append(dto.Redemptions, &join)
+	}
+	return dto
+}
+
+func toJoinRequest(request *pool.JoinRequest) *shared.JoinRequest {
+	return &shared.JoinRequest{
+		ID:         request.ID,
+		Invitation: request.Invitation,
+		Node:       request.Node,
+		Address:    request.Address,
+		Status:     shared.JoinStatus(request.Status),
+		Created:    request.Created,
+		Expires:    request.Expires,
 	}
-	return info, nil
 }
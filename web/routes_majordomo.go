@@ -2,13 +2,30 @@ package web
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gen2brain/beeep"
+	"github.com/reddec/jsonrpc2"
 	"github.com/tinc-boot/tincd/network"
+	"log"
+	"net"
+	"strings"
 
 	"tinc-web-boot/pool"
 	"tinc-web-boot/web/shared"
 )
 
+// JSON-RPC error codes of majordomo join (same as HTTP statuses). Join could be also rejected with ForbiddenError
+// if invitation is not valid for network or node
+const (
+	PendingError     = 202 // join request is waiting for approval
+	InvalidNodeError = 400 // joining node has invalid name, key or subnet
+	ConflictError    = 409 // node name is used by another member of network
+)
+
 func NewMajordomo(pool *pool.Pool) *majordomoImpl {
 	return &majordomoImpl{
 		pool: pool,
@@ -20,13 +37,170 @@ type majordomoImpl struct {
 }
 
 func (srv *majordomoImpl) Join(ctx context.Context, network string, self *network.Node) (*shared.Sharing, error) {
+	client, ok := callerFromContext(ctx)
+	if !ok || client.Token == nil {
+		return nil, joinError(ForbiddenError, "invitation is required", nil)
+	}
+	if scope, _ := client.Claims["network"].(string); scope != network {
+		return nil, joinError(ForbiddenError, "invitation is issued for another network", map[string]string{"network": network})
+	}
 	ntw, err := srv.pool.Network(network)
 	if err != nil {
 		return nil, err
 	}
-	err = ntw.Put(self)
+	if err := checkJoiningNode(ntw, client.Claims, self); err != nil {
+		return nil, err
+	}
+	invitation, err := srv.pool.Invitation(network, client.Token.ID)
 	if err != nil {
-		return nil, fmt.Errorf("import node %s: %w", self.Name, err)
+		log.Println("[guard]", "join without invitation:", err)
+		return nil, joinError(ForbiddenError, "invitation is not found", nil)
+	}
+	var request *pool.JoinRequest
+	if invitation.Approval {
+		request, err = srv.requestApproval(network, invitation.ID, self, client.Address)
+		if err != nil {
+			return nil, err
+		}
+		// node is joined as it was approved
+		self = request.Node
+	}
+	_, err = srv.pool.RedeemInvitation(network, client.Token.ID, pool.Redemption{
+		Node:    self.Name,
+		Address: client.Address,
+	}, func() error {
+		assigned, err := srv.pool.Assign(network, self)
+		if err != nil {
+			return fmt.Errorf("assign address to node %s: %w", self.Name, err)
+		}
+		return putJoiningNode(srv.pool, ntw, assigned)
+	})
+	if errors.Is(err, pool.ErrInvitationNotAccepted) {
+		return nil, joinError(ForbiddenError, err.Error(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request != nil {
+		if err := srv.pool.CompleteJoin(network, request.ID); err != nil {
+			log.Println("[WARN]", "complete join request:", err)
+		}
+	}
+	return signedShare(srv.pool, network)
+}
+
+// get approved request of node. New requests are announced by desktop notification
+func (srv *majordomoImpl) requestApproval(network, invitation string, self *network.Node, address string) (*pool.JoinRequest, error) {
+	request, created, err := srv.pool.RequestJoin(network, invitation, self, address)
+	if errors.Is(err, pool.ErrInvitationNotAccepted) {
+		return nil, joinError(ForbiddenError, err.Error(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		log.Println("node", self.Name, "from", address, "requested to join", network, "- request", request.ID)
+		if err := beeep.Notify("tinc-web-boot", "Node "+self.Name+" requests to join network "+network, ""); err != nil {
+			log.Println("[WARN]", "notify about join request:", err)
+		}
+	}
+	switch request.Status {
+	case pool.JoinApproved:
+		return request, nil
+	case pool.JoinRejected:
+		return nil, joinError(ForbiddenError, "join request is rejected", map[string]string{"id": request.ID})
+	default:
+		return nil, joinError(PendingError, "join request is waiting for approval", map[string]string{"id": request.ID})
+	}
+}
+
+// validate joining node against network and invitation scope
+func checkJoiningNode(ntw *network.Network, claims jwt.MapClaims, node *network.Node) error {
+	if node == nil || !network.IsValidNodeName(node.Name) {
+		return joinError(InvalidNodeError, "invalid node name", nil)
+	}
+	// node is saved as host file, so values should not break its format
+	if block, _ := pem.Decode([]byte(node.PublicKey)); block == nil || block.Type != "RSA PUBLIC KEY" {
+		return joinError(InvalidNodeError, "invalid public key of node", map[string]string{"node": node.Name})
+	} else if _, err := x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+		return joinError(InvalidNodeError, "invalid public key of node", map[string]string{"node": node.Name})
+	}
+	if existing, err := ntw.Node(node.Name); err == nil && !sameKey(existing, node) {
+		return nameConflict(node.Name)
+	}
+	for _, addr := range node.Address {
+		if addr.Host == "" || strings.ContainsAny(addr.Host, " \t\r\n") {
+			return joinError(InvalidNodeError, "invalid public address of node", map[string]string{"node": node.Name})
+		}
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		return err
+	}
+	if scope, _ := claims["subnet"].(string); scope != self.Subnet {
+		return joinError(ForbiddenError, "network subnet changed after invitation", map[string]string{"subnet": self.Subnet})
+	}
+	_, subnet, err := net.ParseCIDR(self.Subnet)
+	if err != nil {
+		return fmt.Errorf("parse network subnet: %w", err)
+	}
+	_, nodeSubnet, err := net.ParseCIDR(node.Subnet)
+	if err != nil {
+		return joinError(InvalidNodeError, "invalid subnet of node", map[string]string{"node": node.Name, "subnet": node.Subnet})
+	}
+	networkBits, _ := subnet.Mask.Size()
+	nodeBits, _ := nodeSubnet.Mask.Size()
+	if nodeBits < networkBits || !subnet.Contains(nodeSubnet.IP) {
+		return joinError(InvalidNodeError, fmt.Sprintf("node subnet %s is not inside network subnet %s", nodeSubnet, subnet), map[string]string{
+			"node":   node.Name,
+			"subnet": self.Subnet,
+		})
+	}
+	if node.IP != "" && net.ParseIP(node.IP) == nil {
+		return joinError(InvalidNodeError, "invalid address of node", map[string]string{"node": node.Name, "ip": node.IP})
+	}
+	return nil
+}
+
+// save joining node. Name of existing member could be used only with same key (re-join), so members could not be
+// impersonated. Version is increased to replace previous record of node
+func putJoiningNode(p *pool.Pool, ntw *network.Network, node *network.Node) error {
+	if existing, err := ntw.Node(node.Name); err == nil {
+		if !sameKey(existing, node) {
+			return nameConflict(node.Name)
+		}
+		if existing.Version >= node.Version {
+			update := *node
+			update.Version = existing.Version + 1
+			node = &update
+		}
+	}
+	if err := p.PutNode(ntw, node); err != nil {
+		return fmt.Errorf("import node %s: %w", node.Name, err)
+	}
+	// put silently skips nodes which are not newer than saved
+	stored, err := ntw.Node(node.Name)
+	if err != nil {
+		return fmt.Errorf("import node %s: %w", node.Name, err)
+	}
+	if !sameKey(stored, node) || stored.IP != node.IP || stored.Version != node.Version {
+		return fmt.Errorf("import node %s: node is not saved", node.Name)
+	}
+	return nil
+}
+
+func sameKey(a, b *network.Node) bool {
+	return strings.TrimSpace(a.PublicKey) == strings.TrimSpace(b.PublicKey)
+}
+
+func nameConflict(node string) *jsonrpc2.Error {
+	return joinError(ConflictError, "node name is used by another member", map[string]string{"node": node})
+}
+
+func joinError(code int, message string, data map[string]string) *jsonrpc2.Error {
+	var err = &jsonrpc2.Error{Code: code, Message: message}
+	if data != nil {
+		err.Data = data
 	}
-	return NewShare(ntw)
+	return err
 }
@@ -3,7 +3,6 @@ package web
 import (
 	"context"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gen2brain/beeep"
 	"net"
 	"strconv"
@@ -13,23 +12,57 @@ import (
 )
 
 type uiRoutes struct {
-	key           string
+	tokens        *tokenRegistry
+	codes         *loginCodes
 	port          uint16
 	publicAddress []string
 	config        shared.Config
 	pool          *pool.Pool
 }
 
-func (srv *uiRoutes) issueToken(duration time.Duration, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iat":  time.Now().Add(duration),
-		"role": role,
-	})
-	return token.SignedString([]byte(srv.key))
+func (srv *uiRoutes) IssueAccessToken(ctx context.Context, validDays uint) (string, error) {
+	return srv.IssueToken(ctx, shared.RoleAdmin, "", validDays)
 }
 
-func (srv *uiRoutes) IssueAccessToken(ctx context.Context, validDays uint) (string, error) {
-	return srv.issueToken(time.Duration(24*validDays)*time.Hour, "admin")
+func (srv *uiRoutes) IssueToken(ctx context.Context, role shared.Role, label string, validDays uint) (string, error) {
+	if role == shared.RoleMajordomo || !isValidRole(role) {
+		return "", fmt.Errorf("role %q could not be issued", role)
+	}
+	token, _, err := srv.tokens.Issue(role, label, time.Duration(24*validDays)*time.Hour, nil)
+	return token, err
+}
+
+// Check that token is issued, signed by active key and not expired
+func (srv *uiRoutes) IsValidToken(token string) bool {
+	_, _, err := srv.tokens.Check(token)
+	return err == nil
+}
+
+// Generate one-time code which could be exchanged for session by /auth/login
+func (srv *uiRoutes) IssueLoginCode() (string, error) {
+	return srv.codes.Issue()
+}
+
+func (srv *uiRoutes) ListTokens(ctx context.Context) ([]*shared.Token, error) {
+	return srv.tokens.List(), nil
+}
+
+func (srv *uiRoutes) RevokeToken(ctx context.Context, id string) (bool, error) {
+	return srv.tokens.Revoke(id)
+}
+
+func (srv *uiRoutes) SigningKeys(ctx context.Context) ([]*shared.SigningKey, error) {
+	return srv.tokens.keys.Keys(), nil
+}
+
+func (srv *uiRoutes) RotateKey(ctx context.Context, grace time.Duration) ([]*shared.SigningKey, error) {
+	if grace < 0 {
+		return nil, fmt.Errorf("grace period should not be negative")
+	}
+	if err := srv.tokens.keys.Rotate(grace); err != nil {
+		return nil, err
+	}
+	return srv.tokens.keys.Keys(), nil
 }
 
 func (srv *uiRoutes) Notify(ctx context.Context, title, message string) (bool, error) {
@@ -84,3 +117,13 @@ func (srv *uiRoutes) Endpoints(ctx context.Context) ([]shared.Endpoint, error) {
 func (srv *uiRoutes) Configuration(ctx context.Context) (*shared.Config, error) {
 	return &srv.config, nil
 }
+
+func (srv *uiRoutes) Binaries(ctx context.Context) ([]*shared.TincBinary, error) {
+	list := srv.pool.Binaries()
+	var ans = make([]*shared.TincBinary, 0, len(list))
+	for _, bin := range list {
+		dto := shared.TincBinary(*bin)
+		ans = append(ans, &dto)
+	}
+	return ans, nil
+}
@@ -0,0 +1,179 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"github.com/gen2brain/beeep"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"tinc-web-boot/web/shared"
+)
+
+const (
+	sessionCookie     = "tinc-web-session"
+	sessionLifetime   = 24 * time.Hour
+	loginCodeLifetime = 5 * time.Minute
+	loginCodeSize     = 5 // bytes, 8 chars in base32
+	maxLoginCodes     = 3 // pending codes requested by notification
+)
+
+// One-time codes which could be traded for session
+type loginCodes struct {
+	lock  sync.Mutex
+	codes map[string]time.Time // code -> expiration
+}
+
+// Generate new one-time login code
+func (lc *loginCodes) Issue() (string, error) {
+	var data = make([]byte, loginCodeSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(data)
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	lc.cleanup()
+	if lc.codes == nil {
+		lc.codes = make(map[string]time.Time)
+	}
+	lc.codes[code] = time.Now().Add(loginCodeLifetime)
+	return code, nil
+}
+
+// Use code. Returns false if code is unknown, already used or expired
+func (lc *loginCodes) Use(code string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	expires, ok := lc.codes[code]
+	delete(lc.codes, code)
+	return ok && time.Now().Before(expires)
+}
+
+func (lc *loginCodes) Pending() int {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	lc.cleanup()
+	return len(lc.codes)
+}
+
+func (lc *loginCodes) cleanup() {
+	now := time.Now()
+	for code, expires := range lc.codes {
+		if now.After(expires) {
+			delete(lc.codes, code)
+		}
+	}
+}
+
+type loginRequest struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+// exchange one-time code to session (token in HttpOnly cookie)
+func (srv *uiRoutes) login(gctx *gin.Context) {
+	var req loginRequest
+	if err := gctx.ShouldBind(&req); err != nil {
+		gctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if !srv.codes.Use(req.Code) {
+		log.Println("[guard]", "invalid login code from", gctx.ClientIP())
+		gctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	token, _, err := srv.tokens.Issue(shared.RoleAdmin, "session from "+gctx.ClientIP(), sessionLifetime, nil)
+	if err != nil {
+		log.Println("[guard]", "issue session:", err)
+		gctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(gctx, token, int(sessionLifetime/time.Second))
+	gctx.JSON(http.StatusOK, gin.H{"expires": time.Now().Add(sessionLifetime)})
+}
+
+// revoke session and clear cookie
+func (srv *uiRoutes) logout(gctx *gin.Context) {
+	if token, err := gctx.Cookie(sessionCookie); err == nil {
+		if _, info, err := srv.tokens.Check(token); err == nil {
+			if _, err := srv.tokens.Revoke(info.ID); err != nil {
+				log.Println("[guard]", "revoke session:", err)
+			}
+		}
+	}
+	setSessionCookie(gctx, "", -1)
+	gctx.Status(http.StatusNoContent)
+}
+
+// send one-time login code as desktop notification, so only person in front of the machine could use it
+func (srv *uiRoutes) sendLoginCode(gctx *gin.Context) {
+	if srv.codes.Pending() >= maxLoginCodes {
+		gctx.AbortWithStatus(http.StatusTooManyRequests)
+		return
+	}
+	code, err := srv.codes.Issue()
+	if err != nil {
+		gctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if err := beeep.Notify("tinc-web-boot", "Login code: "+code, ""); err != nil {
+		log.Println("[guard]", "send login code:", err)
+		gctx.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+	gctx.Status(http.StatusNoContent)
+}
+
+func setSessionCookie(gctx *gin.Context, value string, maxAge int) {
+	http.SetCookie(gctx.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   gctx.Request.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// token from Authorization header or session cookie. Cookie is accepted for websocket upgrades (origin is checked
+// by upgrader) and for JSON requests only, which could not be made by cross-site forms
+func requestToken(gctx *gin.Context) (string, error) {
+	if header := gctx.GetHeader("Authorization"); header != "" {
+		const prefix = "Bearer "
+		if !strings.HasPrefix(header, prefix) {
+			return "", fmt.Errorf("unsupported authorization scheme")
+		}
+		return strings.TrimSpace(header[len(prefix):]), nil
+	}
+	token, err := gctx.Cookie(sessionCookie)
+	if err != nil {
+		return "", fmt.Errorf("no token in request")
+	}
+	if gctx.Request.Method != http.MethodGet && gctx.ContentType() != gin.MIMEJSON {
+		return "", fmt.Errorf("session cookie is accepted only for JSON requests")
+	}
+	return token, nil
+}
+
+var tokenInPath = regexp.MustCompile(`^/(api|majordomo)/[^/]+`)
+
+// access log without tokens
+func logFormatter(param gin.LogFormatterParams) string {
+	path := tokenInPath.ReplaceAllString(param.Path, "/$1/***")
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		path,
+		param.ErrorMessage,
+	)
+}
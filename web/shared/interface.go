@@ -7,9 +7,10 @@ import (
 )
 
 type Network struct {
-	Name    string          `json:"name"`
-	Running bool            `json:"running"`
-	Config  *network.Config `json:"config,omitempty"` // only for specific request
+	Name      string          `json:"name"`
+	Running   bool            `json:"running"`
+	Autostart bool            `json:"autostart"`
+	Config    *network.Config `json:"config,omitempty"` // only for specific request
 }
 
 type PeerInfo struct {
@@ -18,10 +19,217 @@ type PeerInfo struct {
 	Configuration network.Node `json:"config"`
 }
 
+// Options of invitation to network
+type InviteOptions struct {
+	Lifetime time.Duration `json:"lifetime"`
+	MaxUses  int           `json:"max_uses,omitempty"`  // 0 - unlimited
+	NodeName string        `json:"node_name,omitempty"` // only node with this name could join (empty - any)
+	Approval bool          `json:"approval,omitempty"`  // joins should be approved by admin
+}
+
+// Invitation with Majordomo link
+type Invite struct {
+	Link       string      `json:"link"`
+	Invitation *Invitation `json:"invitation"`
+}
+
+// Invitation to join network (majordomo link). Invitation ID is same as ID of link token
+type Invitation struct {
+	ID          string        `json:"id"`
+	NodeName    string        `json:"node_name,omitempty"` // only node with this name could join (empty - any)
+	MaxUses     int           `json:"max_uses,omitempty"`  // maximum number of joins (0 - unlimited)
+	Approval    bool          `json:"approval,omitempty"`  // joins should be approved by admin (see JoinRequest)
+	Created     time.Time     `json:"created"`
+	Expires     time.Time     `json:"expires"`
+	Revoked     bool          `json:"revoked,omitempty"`
+	Redemptions []*Redemption `json:"redemptions,omitempty"`
+}
+
+// Invitation could be used for joining
+func (inv *Invitation) IsActive() bool {
+	return !inv.Revoked && time.Now().Before(inv.Expires) && (inv.MaxUses <= 0 || len(inv.Redemptions) < inv.MaxUses)
+}
+
+// Join by invitation
+type Redemption struct {
+	Node    string    `json:"node"`
+	Address string    `json:"address,omitempty"` // remote address of joined node
+	Time    time.Time `json:"time"`
+}
+
+type JoinStatus string
+
+const (
+	JoinPending  JoinStatus = "pending"
+	JoinApproved JoinStatus = "approved"
+	JoinRejected JoinStatus = "rejected"
+)
+
+// Request to join network by invitation which requires approval. Request is kept until node joined
+// or invitation expired
+type JoinRequest struct {
+	ID         string        `json:"id"`
+	Invitation string        `json:"invitation"`
+	Node       *network.Node `json:"node"`
+	Address    string        `json:"address,omitempty"` // remote address of joining node
+	Status     JoinStatus    `json:"status"`
+	Created    time.Time     `json:"created"`
+	Expires    time.Time     `json:"expires"`
+}
+
 type Sharing struct {
-	Name   string          `json:"name"`
-	Subnet string          `json:"subnet"`
-	Nodes  []*network.Node `json:"node,omitempty"`
+	Name      string          `json:"name"`
+	Subnet    string          `json:"subnet"`
+	Nodes     []*network.Node `json:"node,omitempty"`
+	Signature *Signature      `json:"signature,omitempty"`
+	Encrypted *Encrypted      `json:"encrypted,omitempty"` // if defined, other fields are empty: document is encrypted
+}
+
+// Sharing document encrypted by passphrase. Envelope describes how to derive key and decrypt document
+type Encrypted struct {
+	Format string    `json:"format"` // always tinc-web-boot/sharing
+	KDF    KDFParams `json:"kdf"`
+	Cipher string    `json:"cipher"` // aes-256-gcm
+	Nonce  []byte    `json:"nonce"`
+	Data   []byte    `json:"data"` // encrypted JSON of signed sharing document
+}
+
+// Parameters of key derivation from passphrase
+type KDFParams struct {
+	Name string `json:"name"` // scrypt
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// Options of network sharing
+type ShareOptions struct {
+	Passphrase string `json:"passphrase"` // passphrase for document encryption
+}
+
+// Detached signature of sharing document by key of network owner
+type Signature struct {
+	Signer string `json:"signer"` // fingerprint of signer key
+	Key    []byte `json:"key"`    // ed25519 public key of signer
+	Value  []byte `json:"value"`  // signature of JSON document without signature
+}
+
+// Options of network import
+type ImportOptions struct {
+	Name       string `json:"name,omitempty"`       // local name of network (empty - name from document)
+	Signer     string `json:"signer,omitempty"`     // expected fingerprint of signer key, received by trusted channel
+	Force      bool   `json:"force,omitempty"`      // import unsigned document or document signed by untrusted key
+	Passphrase string `json:"passphrase,omitempty"` // passphrase for encrypted document
+}
+
+// Result of network import
+type Imported struct {
+	Network *Network `json:"network"`
+	Signer  string   `json:"signer,omitempty"` // fingerprint of signer key (empty for unsigned document)
+	Trusted bool     `json:"trusted"`          // signer was expected, trusted before or is owner of network
+}
+
+// Backup of networks
+type Backup struct {
+	Manifest *BackupManifest `json:"manifest"`
+	Archive  []byte          `json:"archive"` // tar.gz archive with manifest and networks files
+}
+
+// Description of backup archive (tar.gz with manifest and files of networks)
+type BackupManifest struct {
+	Version  int              `json:"version"`
+	Created  time.Time        `json:"created"`
+	Networks []*BackupNetwork `json:"networks"`
+}
+
+// Network saved in backup
+type BackupNetwork struct {
+	Name      string   `json:"name"`
+	Node      string   `json:"node"`   // self node name
+	Subnet    string   `json:"subnet"` // network subnet (CIDR)
+	Autostart bool     `json:"autostart"`
+	Files     []string `json:"files"` // files relative to network directory: keys, hosts, scripts, ...
+}
+
+// Options of restore from backup
+type RestoreOptions struct {
+	Networks     []string `json:"networks,omitempty"`      // restore only listed networks (empty - all)
+	SkipExisting bool     `json:"skip_existing,omitempty"` // do not touch existent networks (by default they are moved to trash and replaced)
+}
+
+// Result of restore from backup
+type RestoreResult struct {
+	Restored []string `json:"restored"`
+	Skipped  []string `json:"skipped,omitempty"`
+}
+
+// Template of network settings used for network creation
+type Template struct {
+	Name        string            `json:"name"`
+	Subnet      string            `json:"subnet,omitempty"`      // default subnet (CIDR)
+	DeviceType  string            `json:"device_type,omitempty"` // device type (tap, tun, ...)
+	Mode        string            `json:"mode,omitempty"`        // router, switch or hub
+	MinPort     uint16            `json:"min_port,omitempty"`    // lowest listening port (inclusive)
+	MaxPort     uint16            `json:"max_port,omitempty"`    // highest listening port (inclusive)
+	Address     []network.Address `json:"address,omitempty"`     // public addresses
+	Compression int               `json:"compression,omitempty"` // compression level (0-11), stored in conf.d (tincd 1.1+)
+	Scripts     map[string]string `json:"scripts,omitempty"`     // user scripts (host-up, subnet-up, ...) content by name
+}
+
+// Removed network that could be restored till expiration
+type TrashItem struct {
+	ID      string    `json:"id"`
+	Network string    `json:"network"`
+	Removed time.Time `json:"removed"`
+	Expires time.Time `json:"expires"`
+}
+
+// Runtime status of network
+type Status struct {
+	Network     string        `json:"network"`
+	Running     bool          `json:"running"`
+	PID         int           `json:"pid,omitempty"`        // process ID of tincd (from pid file)
+	Started     *time.Time    `json:"started,omitempty"`    // last (re)start time of tincd
+	Uptime      time.Duration `json:"uptime,omitempty"`     // time since last (re)start
+	Restarts    int           `json:"restarts"`             // restarts made by supervisor
+	LastError   string        `json:"last_error,omitempty"` // last exit error of tincd
+	Interface   string        `json:"interface,omitempty"`  // network interface name
+	Addresses   []string      `json:"addresses,omitempty"`  // addresses assigned to the interface
+	Port        uint16        `json:"port"`                 // listening port
+	KnownPeers  int           `json:"known_peers"`          // number of defined nodes (except self)
+	OnlinePeers int           `json:"online_peers"`         // number of connected nodes
+}
+
+// Result of self node upgrade
+type UpgradeResult struct {
+	Node            *network.Node `json:"node"`             // upgraded self node
+	RestartRequired bool          `json:"restart_required"` // changes could be applied only by restart (running network is restarted)
+	Reloaded        bool          `json:"reloaded"`         // running network reloaded configuration without restart
+}
+
+type RestartMode string
+
+const (
+	RestartNever     RestartMode = "never"
+	RestartOnFailure RestartMode = "on-failure"
+	RestartAlways    RestartMode = "always"
+)
+
+// Policy of tincd restarts after unexpected exit. Empty fields are replaced by defaults
+type RestartPolicy struct {
+	Mode        RestartMode   `json:"mode"`                   // never, on-failure (default) or always
+	MinBackoff  time.Duration `json:"min_backoff,omitempty"`  // delay before first restart
+	MaxBackoff  time.Duration `json:"max_backoff,omitempty"`  // maximum delay between restarts
+	MaxRestarts int           `json:"max_restarts,omitempty"` // maximum number of restarts in window, negative means unlimited
+	Window      time.Duration `json:"window,omitempty"`       // window to count restarts
+}
+
+// VPN address leased to node by address authority (node that invites others)
+type Lease struct {
+	Node    string    `json:"node"`
+	IP      string    `json:"ip"`
+	Updated time.Time `json:"updated"`
 }
 
 // Public Tinc-Web API (json-rpc 2.0)
@@ -30,33 +238,103 @@ type TincWeb interface {
 	Networks(ctx context.Context) ([]*Network, error)
 	// Detailed network info
 	Network(ctx context.Context, name string) (*Network, error)
-	// Create new network if not exists
+	// Create new network if not exists. Empty subnet means automatically allocated free subnet.
+	// Subnet should not overlap with other networks and host routes
 	Create(ctx context.Context, name, subnet string) (*Network, error)
-	// Remove network (returns true if network existed)
+	// Create new network if not exists with settings from template (or default settings if template name is empty).
+	// Empty subnet means subnet from template or automatically allocated free subnet
+	CreateFromTemplate(ctx context.Context, name, subnet, template string) (*Network, error)
+	// Rename network. Running network is restarted, autostart and interface name are kept
+	Rename(ctx context.Context, network, newName string) (*Network, error)
+	// Create new network with settings of existent network (keys and self node are generated).
+	// Empty subnet means automatically allocated free subnet
+	Clone(ctx context.Context, source, network, subnet string) (*Network, error)
+	// Remove network (returns true if network existed).
+	// Network is stopped and moved to trash, from where it could be restored during retention period
 	Remove(ctx context.Context, network string) (bool, error)
+	// Templates for new networks
+	Templates(ctx context.Context) ([]*Template, error)
+	// Template by name
+	Template(ctx context.Context, name string) (*Template, error)
+	// Create or replace template
+	SaveTemplate(ctx context.Context, template Template) (*Template, error)
+	// Remove template (returns true if template existed)
+	RemoveTemplate(ctx context.Context, name string) (bool, error)
+	// Removed networks that could be restored
+	Trash(ctx context.Context) ([]*TrashItem, error)
+	// Restore removed network from trash by item ID
+	Restore(ctx context.Context, id string) (*Network, error)
+	// Backup of all networks (keys, hosts, scripts and autostart state) in one archive
+	Backup(ctx context.Context) (*Backup, error)
+	// Restore networks from backup archive. Existent networks are moved to trash and replaced (or skipped if requested).
+	// Running networks are started again after restore
+	RestoreBackup(ctx context.Context, archive []byte, options RestoreOptions) (*RestoreResult, error)
 	// Start or re-start network
 	Start(ctx context.Context, network string) (*Network, error)
 	// Stop network
 	Stop(ctx context.Context, network string) (*Network, error)
+	// Runtime status of network: process, uptime, restarts, interface and peers
+	Status(ctx context.Context, network string) (*Status, error)
 	// Peers brief list in network  (briefly, without config)
 	Peers(ctx context.Context, network string) ([]*PeerInfo, error)
 	// Peer detailed info by in the network
 	Peer(ctx context.Context, network, name string) (*PeerInfo, error)
 	// Import another tinc-web network configuration file.
 	// It means let nodes defined in config join to the network.
-	// Return created (or used) network with full configuration
+	// Return created (or used) network with full configuration.
+	// Same as ImportSigned without options: unsigned documents and documents signed by untrusted keys are rejected
 	Import(ctx context.Context, sharing Sharing) (*Network, error)
-	// Share network and generate configuration file.
+	// Import network configuration file signed by trusted key: key with expected fingerprint, owner key of network
+	// or key used for previous imports. Unsigned documents and documents signed by untrusted keys are rejected
+	// (code 403, signer fingerprint in data) unless forced. Documents with invalid signature are always rejected.
+	// Encrypted document is decrypted by passphrase from options before checks.
+	// Return created (or used) network with full configuration and signer of document
+	ImportSigned(ctx context.Context, sharing Sharing, options ImportOptions) (*Imported, error)
+	// Share network and generate configuration file signed by owner key of network.
 	Share(ctx context.Context, network string) (*Sharing, error)
+	// Share network and generate signed configuration file encrypted by passphrase from options
+	ShareEncrypted(ctx context.Context, network string, options ShareOptions) (*Sharing, error)
 	// Node definition in network (aka - self node)
 	Node(ctx context.Context, network string) (*network.Node, error)
 	// Upgrade node parameters.
-	// In some cases requires restart
-	Upgrade(ctx context.Context, network string, update network.Upgrade) (*network.Node, error)
-	// Generate Majordomo request for easy-sharing
+	// Running network reloads configuration or restarts (if port or device changed) automatically
+	Upgrade(ctx context.Context, network string, update network.Upgrade) (*UpgradeResult, error)
+	// Generate Majordomo request for easy-sharing (invitation without limits)
 	Majordomo(ctx context.Context, network string, lifetime time.Duration) (string, error)
-	// Join by Majordomo Link
+	// Generate Majordomo link with limited number of uses and optional fixed node name
+	Invite(ctx context.Context, network string, options InviteOptions) (*Invite, error)
+	// Invitations to network with redemptions
+	Invitations(ctx context.Context, network string) ([]*Invitation, error)
+	// Invitation to network by ID
+	Invitation(ctx context.Context, network, id string) (*Invitation, error)
+	// Revoke invitation, link will not work anymore. Returns true if invitation was active
+	RevokeInvitation(ctx context.Context, network, id string) (bool, error)
+	// Requests to join network by invitations which require approval
+	JoinRequests(ctx context.Context, network string) ([]*JoinRequest, error)
+	// Approve join request. Waiting node joins network on next poll
+	ApproveJoin(ctx context.Context, network, id string) (*JoinRequest, error)
+	// Reject join request
+	RejectJoin(ctx context.Context, network, id string) (*JoinRequest, error)
+	// Join by Majordomo Link. If invitation requires approval, call waits till request is approved by admin
+	// of inviting node (or rejected, or invitation expired)
 	Join(ctx context.Context, url string, start bool) (*Network, error)
+	// Start network automatically with tinc-web-boot
+	EnableAutostart(ctx context.Context, network string) (*Network, error)
+	// Do not start network automatically with tinc-web-boot
+	DisableAutostart(ctx context.Context, network string) (*Network, error)
+	// Names of networks that will be started automatically
+	AutostartNetworks(ctx context.Context) ([]string, error)
+	// Effective policy of tincd restarts after unexpected exit
+	RestartPolicy(ctx context.Context, network string) (*RestartPolicy, error)
+	// Set custom restart policy for the network. Empty mode resets policy to default.
+	// Applied immediately for running network
+	SetRestartPolicy(ctx context.Context, network string, policy RestartPolicy) (*RestartPolicy, error)
+	// Addresses leased to nodes joined by Majordomo link (this node is address authority for them)
+	Leases(ctx context.Context, network string) ([]*Lease, error)
+	// Reserve address for node. Address should be free and inside network subnet. Node receives it on next join
+	SetLease(ctx context.Context, network, node, ip string) (*Lease, error)
+	// Remove address lease of node (returns true if lease existed)
+	RemoveLease(ctx context.Context, network, node string) (bool, error)
 }
 
 type EndpointKind string
@@ -76,20 +354,73 @@ type Config struct {
 	Binding string `json:"binding"`
 }
 
+// Detected tincd binary
+type TincBinary struct {
+	Path     string   `json:"path"`
+	Version  string   `json:"version,omitempty"`  // empty if version not detected
+	Error    string   `json:"error,omitempty"`    // version detection error
+	Default  bool     `json:"default"`            // used by default for all networks
+	Networks []string `json:"networks,omitempty"` // networks which are configured to use the binary
+}
+
+// Role of API client defined in access token. Admin, operator and viewer are ordered by privileges
+// (each one includes next one), majordomo is allowed only to join networks
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleOperator  Role = "operator"
+	RoleViewer    Role = "viewer"
+	RoleMajordomo Role = "majordomo"
+)
+
+// Issued access token (or majordomo link). Token value is not kept, only its ID (jti claim)
+type Token struct {
+	ID      string    `json:"id"`
+	Label   string    `json:"label,omitempty"`
+	Role    Role      `json:"role"`
+	Issued  time.Time `json:"issued"`
+	Expires time.Time `json:"expires"`
+}
+
+// Key for signing and verification of tokens. Only first (current) key signs new tokens, previous keys are
+// kept for verification till end of grace period
+type SigningKey struct {
+	ID      string    `json:"id"` // kid header of tokens
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"` // zero for current key
+}
+
 // Operations with tinc-web-boot related to UI
 type TincWebUI interface {
 	// Issue and sign token
 	IssueAccessToken(ctx context.Context, validDays uint) (string, error)
+	// Issue and sign token for role (admin, operator or viewer) with optional label
+	IssueToken(ctx context.Context, role Role, label string, validDays uint) (string, error)
+	// Issued and not expired tokens including majordomo links
+	ListTokens(ctx context.Context) ([]*Token, error)
+	// Revoke token by ID. Returns true if token existed
+	RevokeToken(ctx context.Context, id string) (bool, error)
+	// Active signing keys, first one is current
+	SigningKeys(ctx context.Context) ([]*SigningKey, error)
+	// Generate new signing key. Previous keys are valid for verification during grace period.
+	// Returns active signing keys
+	RotateKey(ctx context.Context, grace time.Duration) ([]*SigningKey, error)
 	// Make desktop notification if system supports it
 	Notify(ctx context.Context, title, message string) (bool, error)
 	// Endpoints list to access web UI
 	Endpoints(ctx context.Context) ([]Endpoint, error)
 	// Configuration defined for the instance
 	Configuration(ctx context.Context) (*Config, error)
+	// Tincd binaries (default and per-network) with detected versions
+	Binaries(ctx context.Context) ([]*TincBinary, error)
 }
 
 // Operations for joining public network
 type TincWebMajordomo interface {
-	// Join public network if code matched. Will generate error if node subnet not matched
+	// Join public network if code matched. Node receives free address inside network subnet
+	// (returned sharing contains node with assigned address).
+	// Network should be same as in invitation and node subnet should be inside network subnet, otherwise
+	// join is rejected with error code 403 (invitation) or 400 (node). Code 202 means waiting for approval
 	Join(ctx context.Context, network string, self *network.Node) (*Sharing, error)
 }
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"tinc-web-boot/pool"
+	"tinc-web-boot/web/shared"
+)
+
+const (
+	encryptedFormat = "tinc-web-boot/sharing"
+	encryptedCipher = "aes-256-gcm"
+	kdfScrypt       = "scrypt"
+	// recommended scrypt parameters for interactive usage
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	// limit of memory used to decrypt document (128 * N * R bytes)
+	maxScryptMemory = 256 << 20
+)
+
+// sharing document of network signed by owner key
+func signedShare(p *pool.Pool, name string) (*shared.Sharing, error) {
+	ntw, err := p.Network(name)
+	if err != nil {
+		return nil, err
+	}
+	sharing, err := NewShare(ntw)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.OwnerKey(name)
+	if err != nil {
+		return nil, err
+	}
+	return sharing, signSharing(sharing, key)
+}
+
+func signSharing(sharing *shared.Sharing, key ed25519.PrivateKey) error {
+	sharing.Signature = nil
+	payload, err := json.Marshal(sharing)
+	if err != nil {
+		return err
+	}
+	public := key.Public().(ed25519.PublicKey)
+	sharing.Signature = &shared.Signature{
+		Signer: pool.Fingerprint(public),
+		Key:    public,
+		Value:  ed25519.Sign(key, payload),
+	}
+	return nil
+}
+
+// encrypt document by key derived from passphrase
+func encryptSharing(sharing *shared.Sharing, passphrase string) (*shared.Sharing, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	payload, err := json.Marshal(sharing)
+	if err != nil {
+		return nil, err
+	}
+	params := shared.KDFParams{
+		Name: kdfScrypt,
+		Salt: make([]byte, 16),
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+	}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := sharingCipher(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &shared.Sharing{
+		Encrypted: &shared.Encrypted{
+			Format: encryptedFormat,
+			KDF:    params,
+			Cipher: encryptedCipher,
+			Nonce:  nonce,
+			Data:   aead.Seal(nil, nonce, payload, []byte(encryptedFormat)),
+		},
+	}, nil
+}
+
+// decrypt document. Wrong passphrase and modified document are not distinguished
+func decryptSharing(envelope *shared.Encrypted, passphrase string) (*shared.Sharing, error) {
+	if envelope.Format != encryptedFormat {
+		return nil, fmt.Errorf("unknown format %q of encrypted document", envelope.Format)
+	}
+	if envelope.Cipher != encryptedCipher {
+		return nil, fmt.Errorf("unsupported cipher %q", envelope.Cipher)
+	}
+	params := envelope.KDF
+	if params.Name != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key derivation %q", params.Name)
+	}
+	if params.N <= 1 || params.R <= 0 || params.P <= 0 || 128*params.N*params.R > maxScryptMemory || params.P > 16 {
+		return nil, fmt.Errorf("unsupported parameters of key derivation")
+	}
+	aead, err := sharingCipher(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size")
+	}
+	payload, err := aead.Open(nil, envelope.Nonce, envelope.Data, []byte(encryptedFormat))
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted document")
+	}
+	var sharing shared.Sharing
+	if err := json.Unmarshal(payload, &sharing); err != nil {
+		return nil, fmt.Errorf("parse decrypted document: %w", err)
+	}
+	if sharing.Encrypted != nil {
+		return nil, fmt.Errorf("nested encrypted document")
+	}
+	return &sharing, nil
+}
+
+func sharingCipher(passphrase string, params shared.KDFParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// check signature of document. Returns fingerprint of signer key or empty string for unsigned document
+func verifySharing(sharing shared.Sharing) (string, error) {
+	signature := sharing.Signature
+	if signature == nil {
+		return "", nil
+	}
+	if len(signature.Key) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("invalid signer key")
+	}
+	if pool.Fingerprint(signature.Key) != signature.Signer {
+		return "", fmt.Errorf("signer fingerprint does not match key")
+	}
+	sharing.Signature = nil
+	payload, err := json.Marshal(sharing)
+	if err != nil {
+		return "", err
+	}
+	if !ed25519.Verify(signature.Key, payload, signature.Value) {
+		return "", fmt.Errorf("invalid signature by %s", signature.Signer)
+	}
+	return signature.Signer, nil
+}
+
+// check that signer of document for network is trusted. Signer should be same as expected (if defined)
+func isTrustedSigner(p *pool.Pool, name, signer, expected string) (bool, error) {
+	if expected != "" && signer != expected {
+		return false, fmt.Errorf("document is signed by %q instead of expected %s", signer, expected)
+	}
+	if signer == "" {
+		return false, nil
+	}
+	if expected != "" {
+		return true, nil
+	}
+	ntw, err := p.Network(name)
+	if err != nil {
+		return false, err
+	}
+	if !ntw.IsDefined() {
+		return false, nil
+	}
+	key, err := p.OwnerKey(name)
+	if err != nil {
+		return false, err
+	}
+	if pool.Fingerprint(key.Public().(ed25519.PublicKey)) == signer {
+		return true, nil
+	}
+	list, err := p.TrustedSigners(name)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if item == signer {
+			return true, nil
+		}
+	}
+	return false, nil
+}
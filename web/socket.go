@@ -0,0 +1,110 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"tinc-web-boot/web/shared"
+)
+
+// Credentials of local process connected over unix socket
+type PeerCredentials struct {
+	PID int
+	UID int
+	GID int
+}
+
+// Listen unix socket for local API clients. Clients are authorized by credentials of their processes (see ConnContext),
+// so socket is accessible only by owner and group (if defined, -1 - keep default group)
+func ListenSocket(file string, group int) (net.Listener, error) {
+	if !SocketSupported {
+		return nil, fmt.Errorf("unix socket API is not supported on this platform")
+	}
+	if info, err := os.Lstat(file); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", file); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is used by another process", file)
+		}
+		// stale socket from previous run
+		if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(file, 0660); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if group >= 0 {
+		if err := os.Chown(file, -1, group); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+type peerKey struct{}
+
+// Attach credentials of peer process to context of unix socket connection. Should be used as http.Server.ConnContext
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if _, ok := conn.(*net.UnixConn); !ok {
+		return ctx
+	}
+	creds, err := peerCredentials(conn)
+	if err != nil {
+		log.Println("[guard]", "get peer credentials:", err)
+		return ctx
+	}
+	return context.WithValue(ctx, peerKey{}, creds)
+}
+
+func peerFromContext(ctx context.Context) (*PeerCredentials, bool) {
+	creds, ok := ctx.Value(peerKey{}).(*PeerCredentials)
+	return creds, ok
+}
+
+// role of local process: root, owner of the daemon and members of socket groups are admins
+func (cfg Config) peerRole(creds *PeerCredentials) (shared.Role, error) {
+	if creds.UID == 0 || creds.UID == os.Getuid() {
+		return shared.RoleAdmin, nil
+	}
+	if len(cfg.SocketGroups) > 0 {
+		for _, group := range peerGroups(creds) {
+			for _, gid := range cfg.SocketGroups {
+				if group == gid {
+					return shared.RoleAdmin, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("process %d of user %d (group %d) is not allowed", creds.PID, creds.UID, creds.GID)
+}
+
+// primary group of peer process and supplementary groups of its user
+func peerGroups(creds *PeerCredentials) []int {
+	var groups = []int{creds.GID}
+	account, err := user.LookupId(strconv.Itoa(creds.UID))
+	if err != nil {
+		log.Println("[guard]", "lookup user", creds.UID, ":", err)
+		return groups
+	}
+	ids, err := account.GroupIds()
+	if err != nil {
+		log.Println("[guard]", "lookup groups of user", creds.UID, ":", err)
+		return groups
+	}
+	for _, id := range ids {
+		if gid, err := strconv.Atoi(id); err == nil {
+			groups = append(groups, gid)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,181 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"tinc-web-boot/web/shared"
+)
+
+// Registry of issued tokens. Only tokens known by registry are accepted, so removing token from registry revokes it.
+// Registry is kept in file (if defined), expired tokens are removed automatically
+type tokenRegistry struct {
+	keys   *Keyring
+	file   string
+	lock   sync.Mutex
+	tokens map[string]*shared.Token
+}
+
+func newTokenRegistry(keys *Keyring, file string) *tokenRegistry {
+	reg := &tokenRegistry{
+		keys:   keys,
+		file:   file,
+		tokens: make(map[string]*shared.Token),
+	}
+	if err := reg.load(); err != nil {
+		// fail closed: previously issued tokens are not accepted
+		log.Println("[WARN]", "failed to load tokens:", err)
+	}
+	return reg
+}
+
+// Issue signed token for role. Extra claims are added to token as-is
+func (reg *tokenRegistry) Issue(role shared.Role, label string, lifetime time.Duration, extra jwt.MapClaims) (string, *shared.Token, error) {
+	if lifetime <= 0 {
+		return "", nil, fmt.Errorf("token lifetime should be positive")
+	}
+	now := time.Now()
+	info := &shared.Token{
+		ID:      uuid.New().String(),
+		Label:   label,
+		Role:    role,
+		Issued:  now,
+		Expires: now.Add(lifetime),
+	}
+	claims := jwt.MapClaims{}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	claims["jti"] = info.ID
+	claims["iat"] = info.Issued.Unix()
+	claims["exp"] = info.Expires.Unix()
+	claims["role"] = role
+	kid, secret := reg.keys.Current()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		return "", nil, err
+	}
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	reg.tokens[info.ID] = info
+	cp := *info
+	return signed, &cp, reg.save()
+}
+
+// Check token signature, expiration and revocation. Returns token claims
+func (reg *tokenRegistry) Check(token string) (jwt.MapClaims, *shared.Token, error) {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return reg.keys.Find(kid)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, nil, fmt.Errorf("claims not a map")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, nil, fmt.Errorf("token without expiration")
+	}
+	id, _ := claims["jti"].(string)
+	reg.lock.Lock()
+	info, ok := reg.tokens[id]
+	reg.lock.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("token %q is unknown or revoked", id)
+	}
+	if time.Now().After(info.Expires) {
+		return nil, nil, fmt.Errorf("token %s expired", id)
+	}
+	if role, _ := claims["role"].(string); shared.Role(role) != info.Role {
+		return nil, nil, fmt.Errorf("role of token %s not matched", id)
+	}
+	return claims, info, nil
+}
+
+// Not expired tokens sorted by issue time
+func (reg *tokenRegistry) List() []*shared.Token {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	var ans = make([]*shared.Token, 0, len(reg.tokens))
+	for _, info := range reg.tokens {
+		if time.Now().Before(info.Expires) {
+			cp := *info
+			ans = append(ans, &cp)
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Issued.Before(ans[j].Issued)
+	})
+	return ans
+}
+
+// Revoke token. Returns true if token existed
+func (reg *tokenRegistry) Revoke(id string) (bool, error) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	if _, ok := reg.tokens[id]; !ok {
+		return false, nil
+	}
+	delete(reg.tokens, id)
+	return true, reg.save()
+}
+
+func (reg *tokenRegistry) load() error {
+	if reg.file == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(reg.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var list []*shared.Token
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse %s: %w", reg.file, err)
+	}
+	for _, info := range list {
+		reg.tokens[info.ID] = info
+	}
+	return nil
+}
+
+// save tokens to file (if defined) and drop expired. Should be called under lock
+func (reg *tokenRegistry) save() error {
+	var list = make([]*shared.Token, 0, len(reg.tokens))
+	for id, info := range reg.tokens {
+		if time.Now().After(info.Expires) {
+			delete(reg.tokens, id)
+			continue
+		}
+		list = append(list, info)
+	}
+	if reg.file == "" {
+		return nil
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Issued.Before(list[j].Issued)
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(reg.file, data, 0600)
+}